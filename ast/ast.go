@@ -10,6 +10,7 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
 }
 
 
@@ -36,6 +37,13 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -54,22 +62,29 @@ type Identifier struct {
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
 func (i *Identifier) String() string       { return i.Value }
 
 
 type LetStatement struct {
-	Token token.Token 
-	Name  *Identifier
-	Value Expression
+	Token   token.Token 
+	Name    *Identifier
+	Pattern Expression // set instead of Name for let [a, b] = ... and let {a, b} = ...
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos }
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -90,6 +105,7 @@ type GlobalStatement struct {
 
 func (gs *GlobalStatement) statementNode()       {}
 func (gs *GlobalStatement) TokenLiteral() string { return gs.Token.Literal }
+func (gs *GlobalStatement) Pos() token.Position  { return gs.Token.Pos }
 func (gs *GlobalStatement) String() string {
 	var out bytes.Buffer
 
@@ -108,18 +124,20 @@ func (gs *GlobalStatement) String() string {
 
 
 type AssignStatement struct {
-	Token token.Token 
-	Name  *Identifier
-	Value Expression
+	Token    token.Token
+	Target   Expression
+	Operator string
+	Value    Expression
 }
 
 func (as *AssignStatement) statementNode()       {}
 func (as *AssignStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignStatement) Pos() token.Position  { return as.Token.Pos }
 func (as *AssignStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(as.Name.String())
-	out.WriteString(" = ")
+	out.WriteString(as.Target.String())
+	out.WriteString(" " + as.Operator + " ")
 
 	if as.Value != nil {
 		out.WriteString(as.Value.String())
@@ -138,6 +156,7 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -160,6 +179,7 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Position  { return es.Token.Pos }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -175,6 +195,7 @@ type BlockStatement struct {
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Pos }
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -193,6 +214,7 @@ type IntegerLiteral struct {
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Position  { return il.Token.Pos }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
 
@@ -203,6 +225,7 @@ type FloatLiteral struct {
 
 func (fl *FloatLiteral) expressionNode()      {}
 func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
 func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
 
 
@@ -213,8 +236,36 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+// InterpolatedString is a "Hello ${name}" string. Literal text segments are
+// StringLiterals carrying a TEMPLATE token; every other part is an
+// interpolated expression.
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Pos() token.Position  { return is.Token.Pos }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if sl, ok := part.(*StringLiteral); ok && sl.Token.Type == token.TEMPLATE {
+			out.WriteString(sl.Token.Literal)
+			continue
+		}
+		out.WriteString("${" + part.String() + "}")
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
+
 
 type Boolean struct {
 	Token token.Token
@@ -223,6 +274,7 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
 
@@ -234,6 +286,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -255,6 +308,7 @@ type InfixExpression struct {
 
 func (oe *InfixExpression) expressionNode()      {}
 func (oe *InfixExpression) TokenLiteral() string { return oe.Token.Literal }
+func (oe *InfixExpression) Pos() token.Position  { return oe.Token.Pos }
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -277,6 +331,7 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -294,14 +349,107 @@ func (ie *IfExpression) String() string {
 }
 
 
+// Parameter is a function parameter, optionally with a default value or
+// marked as a rest parameter (...name) that collects remaining arguments.
+// Destructuring parameters set Pattern and leave Name nil.
+type Parameter struct {
+	Token   token.Token
+	Name    *Identifier
+	Pattern Expression
+	Default Expression
+	Rest    bool
+}
+
+func (pa *Parameter) String() string {
+	target := ""
+	if pa.Pattern != nil {
+		target = pa.Pattern.String()
+	} else {
+		target = pa.Name.String()
+	}
+	if pa.Rest {
+		return "..." + target
+	}
+	if pa.Default != nil {
+		return target + " = " + pa.Default.String()
+	}
+	return target
+}
+
+// PatternElement is one entry of a destructuring pattern. Key is only set
+// inside hash patterns; Target is an *Identifier or a nested pattern.
+type PatternElement struct {
+	Key     string
+	Target  Expression
+	Default Expression
+}
+
+func (pe *PatternElement) String() string {
+	out := pe.Target.String()
+	if ident, ok := pe.Target.(*Identifier); pe.Key != "" && (!ok || ident.Value != pe.Key) {
+		out = pe.Key + ": " + out
+	}
+	if pe.Default != nil {
+		out += " = " + pe.Default.String()
+	}
+	return out
+}
+
+// ArrayPattern destructures an array: [first, second = 0, ...rest].
+type ArrayPattern struct {
+	Token    token.Token
+	Elements []*PatternElement
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) expressionNode()      {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) Pos() token.Position  { return ap.Token.Pos }
+func (ap *ArrayPattern) String() string {
+	parts := []string{}
+	for _, el := range ap.Elements {
+		parts = append(parts, el.String())
+	}
+	if ap.Rest != nil {
+		parts = append(parts, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// HashPattern destructures a hash by key: {status, body: text, ...rest}.
+type HashPattern struct {
+	Token   token.Token
+	Entries []*PatternElement
+	Rest    *Identifier
+}
+
+func (hp *HashPattern) expressionNode()      {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) Pos() token.Position  { return hp.Token.Pos }
+func (hp *HashPattern) String() string {
+	parts := []string{}
+	for _, entry := range hp.Entries {
+		parts = append(parts, entry.String())
+	}
+	if hp.Rest != nil {
+		parts = append(parts, "..."+hp.Rest.String())
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
 type FunctionLiteral struct {
 	Token      token.Token 
-	Parameters []*Identifier
+	Name       string
+	Async      bool
+	Generator  bool // declared with function* or contains a yield
+	Arrow      bool // x => expr or (a, b) => { ... }
+	Parameters []*Parameter
 	Body       *BlockStatement
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position  { return fl.Token.Pos }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -310,7 +458,21 @@ func (fl *FunctionLiteral) String() string {
 		params = append(params, p.String())
 	}
 
+	if fl.Arrow {
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(") => ")
+		out.WriteString(fl.Body.String())
+		return out.String()
+	}
+
+	if fl.Async {
+		out.WriteString("async ")
+	}
 	out.WriteString(fl.TokenLiteral())
+	if fl.Generator {
+		out.WriteString("*")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
@@ -320,6 +482,18 @@ func (fl *FunctionLiteral) String() string {
 }
 
 
+// NamedArgument is a call-site argument written as name: value.
+type NamedArgument struct {
+	Token token.Token
+	Name  string
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode()      {}
+func (na *NamedArgument) TokenLiteral() string { return na.Token.Literal }
+func (na *NamedArgument) Pos() token.Position  { return na.Token.Pos }
+func (na *NamedArgument) String() string       { return na.Name + ": " + na.Value.String() }
+
 type CallExpression struct {
 	Token     token.Token 
 	Function  Expression  
@@ -328,6 +502,7 @@ type CallExpression struct {
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position  { return ce.Token.Pos }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -346,31 +521,41 @@ func (ce *CallExpression) String() string {
 
 
 type PropertyAccessExpression struct {
-	Token token.Token 
-	Left  Expression
-	Right *Identifier
+	Token    token.Token 
+	Left     Expression
+	Right    *Identifier
+	Optional bool // a?.b
 }
 
 func (pa *PropertyAccessExpression) expressionNode()      {}
 func (pa *PropertyAccessExpression) TokenLiteral() string { return pa.Token.Literal }
+func (pa *PropertyAccessExpression) Pos() token.Position  { return pa.Token.Pos }
 func (pa *PropertyAccessExpression) String() string {
+	if pa.Optional {
+		return pa.Left.String() + "?." + pa.Right.String()
+	}
 	return pa.Left.String() + "." + pa.Right.String()
 }
 
 
 type IndexExpression struct {
-	Token token.Token 
-	Left  Expression
-	Index Expression
+	Token    token.Token 
+	Left     Expression
+	Index    Expression
+	Optional bool // a?[i]
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Token.Pos }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -379,6 +564,55 @@ func (ie *IndexExpression) String() string {
 }
 
 
+// SliceExpression is xs[low:high]; either bound may be omitted.
+type SliceExpression struct {
+	Token    token.Token
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Optional bool // a?[i:j]
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Pos() token.Position  { return se.Token.Pos }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	if se.Optional {
+		out.WriteString("?")
+	}
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+
+type YieldExpression struct {
+	Token token.Token
+	Value Expression // nil for a bare yield
+}
+
+func (ye *YieldExpression) expressionNode()      {}
+func (ye *YieldExpression) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpression) Pos() token.Position  { return ye.Token.Pos }
+func (ye *YieldExpression) String() string {
+	if ye.Value == nil {
+		return "yield"
+	}
+	return "yield " + ye.Value.String()
+}
+
 type WhileExpression struct {
 	Token     token.Token 
 	Condition Expression
@@ -387,6 +621,7 @@ type WhileExpression struct {
 
 func (we *WhileExpression) expressionNode()      {}
 func (we *WhileExpression) TokenLiteral() string { return we.Token.Literal }
+func (we *WhileExpression) Pos() token.Position  { return we.Token.Pos }
 func (we *WhileExpression) String() string {
 	var out bytes.Buffer
 
@@ -409,6 +644,7 @@ type ForExpression struct {
 
 func (fe *ForExpression) expressionNode()      {}
 func (fe *ForExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe *ForExpression) Pos() token.Position  { return fe.Token.Pos }
 func (fe *ForExpression) String() string {
 	var out bytes.Buffer
 
@@ -436,15 +672,17 @@ func (fe *ForExpression) String() string {
 
 
 type ForEachExpression struct {
-	Token    token.Token 
-	ValueVar string      
-	KeyVar   string      
-	Iterable Expression  
-	Body     *BlockStatement
+	Token        token.Token 
+	ValueVar     string      
+	ValuePattern Expression // set instead of ValueVar for foreach {a, b} in ...
+	KeyVar       string      
+	Iterable     Expression  
+	Body         *BlockStatement
 }
 
 func (fee *ForEachExpression) expressionNode()      {}
 func (fee *ForEachExpression) TokenLiteral() string { return fee.Token.Literal }
+func (fee *ForEachExpression) Pos() token.Position  { return fee.Token.Pos }
 func (fee *ForEachExpression) String() string {
 	var out bytes.Buffer
 
@@ -453,7 +691,11 @@ func (fee *ForEachExpression) String() string {
 		out.WriteString(fee.KeyVar)
 		out.WriteString(", ")
 	}
-	out.WriteString(fee.ValueVar)
+	if fee.ValuePattern != nil {
+		out.WriteString(fee.ValuePattern.String())
+	} else {
+		out.WriteString(fee.ValueVar)
+	}
 	out.WriteString(" in ")
 	out.WriteString(fee.Iterable.String())
 	out.WriteString(") ")
@@ -462,6 +704,85 @@ func (fee *ForEachExpression) String() string {
 	return out.String()
 }
 
+type SelectCase struct {
+	Var     string
+	Channel Expression
+	Body    *BlockStatement
+}
+
+type SelectExpression struct {
+	Token       token.Token
+	Cases       []*SelectCase
+	Timeout     Expression
+	TimeoutBody *BlockStatement
+	Default     *BlockStatement
+}
+
+func (se *SelectExpression) expressionNode()      {}
+func (se *SelectExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SelectExpression) Pos() token.Position  { return se.Token.Pos }
+func (se *SelectExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("select { ")
+	for _, c := range se.Cases {
+		out.WriteString(c.Var + " in " + c.Channel.String() + " " + c.Body.String() + " ")
+	}
+	if se.Timeout != nil {
+		out.WriteString("after " + se.Timeout.String() + " " + se.TimeoutBody.String() + " ")
+	}
+	if se.Default != nil {
+		out.WriteString("else " + se.Default.String() + " ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// MatchArm is one `pattern [if guard] => body` arm of a match expression.
+type MatchArm struct {
+	Pattern Expression
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+type MatchExpression struct {
+	Token   token.Token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) Pos() token.Position  { return me.Token.Pos }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("match " + me.Subject.String() + " { ")
+	for _, arm := range me.Arms {
+		out.WriteString(arm.Pattern.String())
+		if arm.Guard != nil {
+			out.WriteString(" if " + arm.Guard.String())
+		}
+		out.WriteString(" => " + arm.Body.String() + ", ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// RangePattern matches numbers between Low and High, both inclusive.
+type RangePattern struct {
+	Token token.Token
+	Low   Expression
+	High  Expression
+}
+
+func (rp *RangePattern) expressionNode()      {}
+func (rp *RangePattern) TokenLiteral() string { return rp.Token.Literal }
+func (rp *RangePattern) Pos() token.Position  { return rp.Token.Pos }
+func (rp *RangePattern) String() string       { return rp.Low.String() + ".." + rp.High.String() }
+
 
 type ArrayLiteral struct {
 	Token    token.Token 
@@ -470,6 +791,7 @@ type ArrayLiteral struct {
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Pos }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -489,16 +811,18 @@ func (al *ArrayLiteral) String() string {
 type HashLiteral struct {
 	Token token.Token 
 	Pairs map[Expression]Expression
+	Keys  []Expression // keys of Pairs in source order
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -518,6 +842,7 @@ type TryCatchExpression struct {
 
 func (tce *TryCatchExpression) expressionNode()      {}
 func (tce *TryCatchExpression) TokenLiteral() string { return tce.Token.Literal }
+func (tce *TryCatchExpression) Pos() token.Position  { return tce.Token.Pos }
 func (tce *TryCatchExpression) String() string {
 	var out bytes.Buffer
 
@@ -539,6 +864,7 @@ type ThrowStatement struct {
 
 func (ts *ThrowStatement) statementNode()       {}
 func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) Pos() token.Position  { return ts.Token.Pos }
 func (ts *ThrowStatement) String() string {
 	var out bytes.Buffer
 
@@ -560,6 +886,7 @@ type ImportStatement struct {
 
 func (is *ImportStatement) statementNode()       {}
 func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) Pos() token.Position  { return is.Token.Pos }
 func (is *ImportStatement) String() string {
 	var out bytes.Buffer
 
@@ -580,6 +907,7 @@ type SpawnStatement struct {
 
 func (ss *SpawnStatement) statementNode()       {}
 func (ss *SpawnStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SpawnStatement) Pos() token.Position  { return ss.Token.Pos }
 func (ss *SpawnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("spawn ")
@@ -588,6 +916,75 @@ func (ss *SpawnStatement) String() string {
 	return out.String()
 }
 
+type ScheduleStatement struct {
+	Token token.Token
+	Spec  Expression
+	Name  *Identifier
+	Body  *BlockStatement
+}
+
+func (ss *ScheduleStatement) statementNode()       {}
+func (ss *ScheduleStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *ScheduleStatement) Pos() token.Position  { return ss.Token.Pos }
+func (ss *ScheduleStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("schedule ")
+	out.WriteString(ss.Spec.String())
+	if ss.Name != nil {
+		out.WriteString(" as ")
+		out.WriteString(ss.Name.String())
+	}
+	out.WriteString(" ")
+	out.WriteString(ss.Body.String())
+	return out.String()
+}
+
+
+type AsyncExpression struct {
+	Token token.Token 
+	Call  *CallExpression
+}
+
+func (ae *AsyncExpression) expressionNode()      {}
+func (ae *AsyncExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AsyncExpression) Pos() token.Position  { return ae.Token.Pos }
+func (ae *AsyncExpression) String() string {
+	return "async " + ae.Call.String()
+}
+
+
+type AwaitExpression struct {
+	Token token.Token 
+	Value Expression
+}
+
+func (ae *AwaitExpression) expressionNode()      {}
+func (ae *AwaitExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AwaitExpression) Pos() token.Position  { return ae.Token.Pos }
+func (ae *AwaitExpression) String() string {
+	return "(await " + ae.Value.String() + ")"
+}
+
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BreakStatement) String() string       { return "break;" }
+
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ContinueStatement) String() string       { return "continue;" }
+
 
 type TernaryExpression struct {
 	Token       token.Token 
@@ -598,6 +995,7 @@ type TernaryExpression struct {
 
 func (te *TernaryExpression) expressionNode()      {}
 func (te *TernaryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TernaryExpression) Pos() token.Position  { return te.Token.Pos }
 func (te *TernaryExpression) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,65 @@
+package evaluator
+
+import (
+	"base/object"
+	"reflect"
+)
+
+func RegisterAsyncBuiltins() {
+	builtins["await_all"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			futures, errObj := futureArgs("await_all", args)
+			if errObj != nil {
+				return errObj
+			}
+
+			results := make([]object.Object, len(futures))
+			for i, future := range futures {
+				results[i] = awaitFuture(future)
+			}
+			for _, result := range results {
+				if isError(result) {
+					return result
+				}
+			}
+			return &object.Array{Elements: results}
+		},
+	}
+
+	builtins["await_any"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			futures, errObj := futureArgs("await_any", args)
+			if errObj != nil {
+				return errObj
+			}
+			if len(futures) == 0 {
+				return newError("`await_any` needs at least one FUTURE")
+			}
+
+			cases := make([]reflect.SelectCase, len(futures))
+			for i, future := range futures {
+				cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(future.Done())}
+			}
+			chosen, _, _ := reflect.Select(cases)
+			return awaitFuture(futures[chosen])
+		},
+	}
+}
+
+func futureArgs(name string, args []object.Object) ([]*object.Future, *object.Error) {
+	if len(args) == 1 {
+		if arr, ok := args[0].(*object.Array); ok {
+			args = arr.Elements
+		}
+	}
+
+	futures := make([]*object.Future, len(args))
+	for i, arg := range args {
+		future, ok := arg.(*object.Future)
+		if !ok {
+			return nil, newError("arguments to `%s` must be FUTURE, got %s", name, arg.Type())
+		}
+		futures[i] = future
+	}
+	return futures, nil
+}
@@ -2,6 +2,7 @@ package evaluator
 
 import (
 	"base/object"
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -11,9 +12,46 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// strictMode turns out-of-range array and string indexing into an error
+// instead of null; see sys.strict. Like checkOverflow it is a
+// process-global setting.
+var strictMode atomic.Bool
+
+// lineIterator backs file.lines. It reads one line per step without a
+// length limit and closes the file at EOF.
+type lineIterator struct {
+	file   *os.File
+	reader *bufio.Reader
+	done   bool
+}
+
+func (it *lineIterator) Next() (object.Object, bool) {
+	if it.done {
+		return nil, false
+	}
+	line, err := it.reader.ReadString('\n')
+	if err != nil {
+		it.Close()
+		if err != io.EOF {
+			return newError("could not read file: %s", err.Error()), true
+		}
+		if line == "" {
+			return nil, false
+		}
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	return &object.String{Value: line}, true
+}
+
+func (it *lineIterator) Close() error {
+	it.done = true
+	return it.file.Close()
+}
+
 func RegisterBackendBuiltins() {
 	builtins["http.get"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
@@ -112,7 +150,7 @@ func RegisterBackendBuiltins() {
 			timeout := 5 * time.Second
 			if len(args) > 1 {
 				if opts, ok := args[1].(*object.Hash); ok {
-					if t, ok := opts.Pairs["timeout"]; ok {
+					if t, ok := opts.Get("timeout"); ok {
 						if tInt, ok := t.(*object.Integer); ok {
 							timeout = time.Duration(tInt.Value) * time.Second
 						}
@@ -124,22 +162,18 @@ func RegisterBackendBuiltins() {
 			resp, err := client.Get(urlStr.Value)
 			elapsed := time.Since(start).Milliseconds()
 			if err != nil {
-				return &object.Hash{
-					Pairs: map[string]object.Object{
-						"ok":      FALSE,
-						"latency": &object.Integer{Value: elapsed},
-						"error":   &object.String{Value: err.Error()},
-					},
-				}
+				result := object.NewHash()
+				result.Set("ok", FALSE)
+				result.Set("latency", &object.Integer{Value: elapsed})
+				result.Set("error", &object.String{Value: err.Error()})
+				return result
 			}
 			defer resp.Body.Close()
-			return &object.Hash{
-				Pairs: map[string]object.Object{
-					"ok":      TRUE,
-					"status":  &object.Integer{Value: int64(resp.StatusCode)},
-					"latency": &object.Integer{Value: elapsed},
-				},
-			}
+			result := object.NewHash()
+			result.Set("ok", TRUE)
+			result.Set("status", &object.Integer{Value: int64(resp.StatusCode)})
+			result.Set("latency", &object.Integer{Value: elapsed})
+			return result
 		},
 	}
 
@@ -160,6 +194,23 @@ func RegisterBackendBuiltins() {
 		},
 	}
 
+	builtins["file.lines"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			filePath, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `file.lines` must be STRING")
+			}
+			f, err := os.Open(filePath.Value)
+			if err != nil {
+				return newError("could not read file: %s", err.Error())
+			}
+			return &object.Stream{Name: "file.lines", Iter: &lineIterator{file: f, reader: bufio.NewReader(f)}}
+		},
+	}
+
 	builtins["file.write"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) < 2 {
@@ -246,15 +297,15 @@ func RegisterBackendBuiltins() {
 			}
 
 			content, _ := ioutil.ReadFile(path.Value)
-			var data map[string]interface{}
-			json.Unmarshal(content, &data)
-			if data == nil {
-				data = make(map[string]interface{})
+			decoded, _ := decodeJSON(content)
+			data, ok := decoded.(*orderedMap)
+			if !ok {
+				data = newOrderedMap()
 			}
 
-			updateData := baseObjectToGoType(update).(map[string]interface{})
-			for k, v := range updateData {
-				data[k] = v
+			updateData := baseObjectToGoType(update).(*orderedMap)
+			for _, k := range updateData.keys {
+				data.set(k, updateData.values[k])
 			}
 
 			newContent, _ := json.MarshalIndent(data, "", "  ")
@@ -360,6 +411,20 @@ func RegisterBackendBuiltins() {
 		},
 	}
 
+	builtins["sys.strict"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			on, ok := args[0].(*object.Boolean)
+			if !ok {
+				return newError("argument to `sys.strict` must be BOOLEAN, got %s", args[0].Type())
+			}
+			strictMode.Store(on.Value)
+			return NULL
+		},
+	}
+
 	builtins["env.get"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -421,19 +486,20 @@ func doHTTPRequest(method string, urlStr string, body object.Object, opts *objec
 	customHeaders := map[string]string{}
 
 	if opts != nil {
-		if t, ok := opts.Pairs["timeout"]; ok {
+		if t, ok := opts.Get("timeout"); ok {
 			if tInt, ok := t.(*object.Integer); ok {
 				timeout = time.Duration(tInt.Value) * time.Second
 			}
 		}
-		if r, ok := opts.Pairs["retries"]; ok {
+		if r, ok := opts.Get("retries"); ok {
 			if rInt, ok := r.(*object.Integer); ok {
 				retries = int(rInt.Value)
 			}
 		}
-		if h, ok := opts.Pairs["headers"]; ok {
+		if h, ok := opts.Get("headers"); ok {
 			if hHash, ok := h.(*object.Hash); ok {
-				for k, v := range hHash.Pairs {
+				for _, k := range hHash.Keys() {
+					v, _ := hHash.Get(k)
 					customHeaders[k] = v.Inspect()
 				}
 			}
@@ -484,13 +550,11 @@ func doHTTPRequest(method string, urlStr string, body object.Object, opts *objec
 		defer resp.Body.Close()
 		resBody, _ := ioutil.ReadAll(resp.Body)
 
-		return &object.Hash{
-			Pairs: map[string]object.Object{
-				"status":  &object.Integer{Value: int64(resp.StatusCode)},
-				"body":    &object.String{Value: string(resBody)},
-				"headers": goTypeToBaseObject(parseHeaders(resp.Header)),
-			},
-		}
+		result := object.NewHash()
+		result.Set("status", &object.Integer{Value: int64(resp.StatusCode)})
+		result.Set("body", &object.String{Value: string(resBody)})
+		result.Set("headers", goTypeToBaseObject(parseHeaders(resp.Header)))
+		return result
 	}
 
 	return newError("http.%s error: %s", strings.ToLower(method), lastErr.Error())
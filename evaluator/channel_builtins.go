@@ -2,47 +2,95 @@ package evaluator
 
 import (
 	"base/object"
-	"sync"
+	"time"
 )
 
 func RegisterChannelBuiltins() {
 	builtins["chan"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			ch := &ChannelObject{
-				items: []object.Object{},
-				mu:    &sync.Mutex{},
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
 			}
-			sendFn := &object.Builtin{
-				Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
-					if len(innerArgs) != 1 {
-						return newError("chan.send needs exactly 1 argument")
-					}
-					ch.mu.Lock()
-					ch.items = append(ch.items, innerArgs[0])
-					ch.mu.Unlock()
-					return NULL
-				},
-			}
-			readAllFn := &object.Builtin{
-				Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
-					ch.mu.Lock()
-					defer ch.mu.Unlock()
-					elements := make([]object.Object, len(ch.items))
-					copy(elements, ch.items)
-					return &object.Array{Elements: elements}
-				},
-			}
-			return &object.Hash{
-				Pairs: map[string]object.Object{
-					"send":     sendFn,
-					"read_all": readAllFn,
-				},
+			// Only chan() is unbounded; there is no rendezvous channel, so a
+			// capacity of 0 is rejected rather than silently unbounded.
+			capacity := 0
+			if len(args) == 1 {
+				c, ok := args[0].(*object.Integer)
+				if !ok || c.Value < 1 {
+					return newError("argument to `chan` must be a positive INTEGER; use chan() for an unbounded channel")
+				}
+				capacity = int(c.Value)
 			}
+			return object.NewChannel(capacity)
 		},
 	}
 }
 
-type ChannelObject struct {
-	items []object.Object
-	mu    *sync.Mutex
+func channelMethod(ch *object.Channel, name string) object.Object {
+	switch name {
+	case "send":
+		return &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("chan.send needs exactly 1 argument")
+				}
+				if !ch.Send(args[0]) {
+					return newError("send on closed channel")
+				}
+				return NULL
+			},
+		}
+	case "recv":
+		return &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if len(args) > 1 {
+					return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+				}
+				timeout := time.Duration(-1)
+				if len(args) == 1 {
+					d, ok := secondsToDuration(args[0])
+					if !ok {
+						return newError("timeout for `chan.recv` must be INTEGER or FLOAT seconds")
+					}
+					timeout = d
+				}
+				if obj, ok := ch.Recv(timeout); ok {
+					return obj
+				}
+				return NULL
+			},
+		}
+	case "close":
+		return &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if !ch.Close() {
+					return newError("close of closed channel")
+				}
+				return NULL
+			},
+		}
+	case "read_all":
+		return &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				return &object.Array{Elements: ch.Items()}
+			},
+		}
+	case "len":
+		return &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				return &object.Integer{Value: int64(ch.Len())}
+			},
+		}
+	}
+	return nil
+}
+
+func secondsToDuration(obj object.Object) (time.Duration, bool) {
+	switch arg := obj.(type) {
+	case *object.Integer:
+		return time.Duration(arg.Value) * time.Second, true
+	case *object.Float:
+		return time.Duration(arg.Value * float64(time.Second)), true
+	}
+	return 0, false
 }
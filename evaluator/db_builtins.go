@@ -8,6 +8,7 @@ import (
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	_ "modernc.org/sqlite"
@@ -107,13 +108,13 @@ func RegisterDBBuiltins() {
 				if !ok {
 					return newError("data for SQL insert must be HASH")
 				}
-				keys := []string{}
+				keys := hash.Keys()
 				placeholders := []string{}
 				vals := []interface{}{}
-				for k, v := range hash.Pairs {
-					keys = append(keys, k)
+				for _, k := range keys {
 					placeholders = append(placeholders, "?")
-					vals = append(vals, baseObjectToGoType(v))
+					val, _ := hash.Get(k)
+					vals = append(vals, baseObjectToGoType(val))
 				}
 				query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", target, stringsJoin(keys, ","), stringsJoin(placeholders, ","))
 				_, err := c.Exec(query, vals...)
@@ -122,7 +123,7 @@ func RegisterDBBuiltins() {
 				}
 			case *mongo.Client:
 				coll := c.Database("test").Collection(target)
-				_, err := coll.InsertOne(context.TODO(), baseObjectToGoType(data))
+				_, err := coll.InsertOne(context.TODO(), toBSON(baseObjectToGoType(data)))
 				if err != nil {
 					return newError("mongodb insert error: %s", err.Error())
 				}
@@ -165,24 +166,14 @@ func RegisterDBBuiltins() {
 				cols, _ := rows.Columns()
 				results := []object.Object{}
 				for rows.Next() {
-					vals := make([]interface{}, len(cols))
-					ptrs := make([]interface{}, len(cols))
-					for i := range vals {
-						ptrs[i] = &vals[i]
-					}
-					rows.Scan(ptrs...)
-					row := make(map[string]object.Object)
-					for i, col := range cols {
-						row[col] = goTypeToBaseObject(vals[i])
-					}
-					results = append(results, &object.Hash{Pairs: row})
+					results = append(results, scanRow(rows, cols))
 				}
 				return &object.Array{Elements: results}
 			case *mongo.Client:
 				coll := c.Database("test").Collection(args[1].(*object.String).Value)
-				filter := make(map[string]interface{})
+				var filter interface{} = bson.D{}
 				if len(args) > 2 {
-					filter = baseObjectToGoType(args[2]).(map[string]interface{})
+					filter = toBSON(baseObjectToGoType(args[2]))
 				}
 				cursor, err := coll.Find(context.TODO(), filter)
 				if err != nil {
@@ -196,6 +187,55 @@ func RegisterDBBuiltins() {
 		},
 	}
 
+	builtins["db.stream"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments. got=%d, want=2+", len(args))
+			}
+			aliasObj, ok1 := args[0].(*object.String)
+			if !ok1 {
+				return newError("first argument to `db.stream` must be STRING")
+			}
+			conn, exists := dbConnections[aliasObj.Value]
+			if !exists {
+				return newError("no connection for alias: %s", aliasObj.Value)
+			}
+
+			switch c := conn.(type) {
+			case *sql.DB:
+				queryObj, ok := args[1].(*object.String)
+				if !ok {
+					return newError("second argument to SQL `db.stream` must be STRING")
+				}
+				goArgs := make([]interface{}, len(args)-2)
+				for i, arg := range args[2:] {
+					goArgs[i] = baseObjectToGoType(arg)
+				}
+				rows, err := c.Query(queryObj.Value, goArgs...)
+				if err != nil {
+					return newError("sql query error: %s", err.Error())
+				}
+				cols, _ := rows.Columns()
+				return &object.Stream{Name: "db.stream", Iter: &sqlRowIterator{rows: rows, cols: cols}}
+			case *mongo.Client:
+				collName, ok := args[1].(*object.String)
+				if !ok {
+					return newError("second argument to MongoDB `db.stream` must be STRING")
+				}
+				var filter interface{} = bson.D{}
+				if len(args) > 2 {
+					filter = toBSON(baseObjectToGoType(args[2]))
+				}
+				cursor, err := c.Database("test").Collection(collName.Value).Find(context.TODO(), filter)
+				if err != nil {
+					return newError("mongodb find error: %s", err.Error())
+				}
+				return &object.Stream{Name: "db.stream", Iter: &mongoCursorIterator{cursor: cursor}}
+			}
+			return NULL
+		},
+	}
+
 	builtins["db.update"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) < 4 {
@@ -208,8 +248,8 @@ func RegisterDBBuiltins() {
 			}
 			alias := aliasObj.Value
 			target := targetObj.Value
-			match := baseObjectToGoType(args[2])
-			update := baseObjectToGoType(args[3])
+			match := toBSON(baseObjectToGoType(args[2]))
+			update := toBSON(baseObjectToGoType(args[3]))
 
 			conn, _ := dbConnections[alias]
 			switch c := conn.(type) {
@@ -233,7 +273,7 @@ func RegisterDBBuiltins() {
 			}
 			alias := args[0].(*object.String).Value
 			target := args[1].(*object.String).Value
-			match := baseObjectToGoType(args[2])
+			match := toBSON(baseObjectToGoType(args[2]))
 
 			conn, _ := dbConnections[alias]
 			switch c := conn.(type) {
@@ -277,7 +317,7 @@ func RegisterDBBuiltins() {
 				coll := c.Database("test").Collection(target)
 				var docs []interface{}
 				for _, el := range arr.Elements {
-					docs = append(docs, baseObjectToGoType(el))
+					docs = append(docs, toBSON(baseObjectToGoType(el)))
 				}
 				_, err := coll.InsertMany(context.TODO(), docs)
 				if err != nil {
@@ -295,7 +335,7 @@ func RegisterDBBuiltins() {
 			}
 			alias := args[0].(*object.String).Value
 			target := args[1].(*object.String).Value
-			pipeline := baseObjectToGoType(args[2])
+			pipeline := toBSON(baseObjectToGoType(args[2]))
 
 			conn, _ := dbConnections[alias]
 			if c, ok := conn.(*mongo.Client); ok {
@@ -313,6 +353,78 @@ func RegisterDBBuiltins() {
 	}
 }
 
+func scanRow(rows *sql.Rows, cols []string) *object.Hash {
+	vals := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	rows.Scan(ptrs...)
+	row := object.NewHash()
+	for i, col := range cols {
+		row.Set(col, goTypeToBaseObject(vals[i]))
+	}
+	return row
+}
+
+// sqlRowIterator backs db.stream, reading one row per step and closing the
+// result set once it is exhausted.
+type sqlRowIterator struct {
+	rows *sql.Rows
+	cols []string
+	done bool
+}
+
+func (it *sqlRowIterator) Next() (object.Object, bool) {
+	if it.done {
+		return nil, false
+	}
+	if !it.rows.Next() {
+		it.done = true
+		it.rows.Close()
+		if err := it.rows.Err(); err != nil {
+			return newError("sql query error: %s", err.Error()), true
+		}
+		return nil, false
+	}
+	return scanRow(it.rows, it.cols), true
+}
+
+func (it *sqlRowIterator) Close() error {
+	it.done = true
+	return it.rows.Close()
+}
+
+type mongoCursorIterator struct {
+	cursor *mongo.Cursor
+	done   bool
+}
+
+func (it *mongoCursorIterator) Next() (object.Object, bool) {
+	if it.done {
+		return nil, false
+	}
+	if !it.cursor.Next(context.TODO()) {
+		it.done = true
+		err := it.cursor.Err()
+		it.cursor.Close(context.TODO())
+		if err != nil {
+			return newError("mongodb find error: %s", err.Error()), true
+		}
+		return nil, false
+	}
+	var doc bson.D
+	if err := it.cursor.Decode(&doc); err != nil {
+		return newError("mongodb decode error: %s", err.Error()), true
+	}
+	return goTypeToBaseObject(doc), true
+}
+
+func (it *mongoCursorIterator) Close() error {
+	it.done = true
+	return it.cursor.Close(context.TODO())
+}
+
 func stringsJoin(s []string, sep string) string {
 	res := ""
 	for i, v := range s {
@@ -4,18 +4,33 @@ import (
 	"base/ast"
 	"base/object"
 	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"time"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 
 	ImportHandler func(path string) (object.Object, error)
 	KeepAlive     = false
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := evalNode(node, env)
+	if errObj, ok := result.(*object.Error); ok {
+		errObj.Locate(node.Pos())
+	}
+	return result
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 
 	case *ast.Program:
@@ -39,14 +54,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
+		if node.Pattern != nil {
+			return bindPattern(node.Pattern, val, env)
+		}
 		env.Set(node.Name.Value, val)
 	case *ast.AssignStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-
-		env.Update(node.Name.Value, val)
+		return evalAssignStatement(node, env)
 
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
@@ -56,6 +69,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.PrefixExpression:
@@ -69,6 +84,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(left) {
 			return left
 		}
+		if node.Operator == "??" {
+			if left.Type() != object.NULL_OBJ {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
@@ -82,40 +103,42 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalForExpression(node, env)
 	case *ast.ForEachExpression:
 		return evalForEachExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.SelectExpression:
+		return evalSelectExpression(node, env)
 	case *ast.TryCatchExpression:
 		return evalTryCatchExpression(node, env)
 	case *ast.ThrowStatement:
 		return evalThrowStatement(node, env)
 	case *ast.SpawnStatement:
 		return evalSpawnStatement(node, env)
+	case *ast.ScheduleStatement:
+		return evalScheduleStatement(node, env)
+	case *ast.AsyncExpression:
+		return evalAsyncExpression(node, env)
+	case *ast.AwaitExpression:
+		return evalAwaitExpression(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Name: node.Name, Async: node.Async, Generator: node.Generator, Parameters: params, Body: body, Env: env}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-		return applyFunction(env, function, args)
+		return endChain(evalCallExpression(node, env))
 	case *ast.TernaryExpression:
 		return evalTernaryExpression(node, env)
 	case *ast.PropertyAccessExpression:
-		return evalPropertyAccessExpression(node, env)
+		return endChain(evalPropertyAccessExpression(node, env))
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-		return evalIndexExpression(left, index)
+		return endChain(evalIndexNode(node, env))
+	case *ast.SliceExpression:
+		return endChain(evalSliceNode(node, env))
+	case *ast.YieldExpression:
+		return evalYieldExpression(node, env)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -138,6 +161,7 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 		case *object.ReturnValue:
 			return result.Value
 		case *object.Error:
+			result.PushFrame("<main>")
 			return result
 		}
 	}
@@ -151,7 +175,7 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -214,10 +238,16 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if f, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -f.Value}
+	}
 	if right.Type() != object.INTEGER_OBJ {
 		return newError("unknown operator: -%s", right.Type())
 	}
 	value := right.(*object.Integer).Value
+	if value == math.MinInt64 && checkOverflow.Load() {
+		return newError("integer overflow: -(%d)", value)
+	}
 	return &object.Integer{Value: -value}
 }
 
@@ -231,14 +261,22 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 		return &object.String{Value: left.Inspect() + right.Inspect()}
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
-	case left.Type() == object.FLOAT_OBJ || right.Type() == object.FLOAT_OBJ:
+	case isNumber(left) && isNumber(right):
 		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left.Inspect() == right.Inspect())
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left.Inspect() != right.Inspect())
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
+	case isComparison(operator):
+		if cmp, ok := compareObjects(left, right); ok {
+			return nativeBoolToBooleanObject(compareResult(operator, cmp))
+		}
+		if left.Type() != right.Type() {
+			return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		}
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -270,10 +308,16 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 		return &object.Float{Value: leftVal * rightVal}
 	case "/":
 		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -288,14 +332,23 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	rightVal := right.(*object.Integer).Value
 
 	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+	case "/", "%":
+		if rightVal == 0 {
+			return newError("division by zero: %d %s 0", leftVal, operator)
+		}
+	case "<<", ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d", rightVal)
+		}
+	}
+
+	switch operator {
+	case "+", "-", "*", "/", "<<":
+		result := integerArithmetic(operator, leftVal, rightVal)
+		if checkOverflow.Load() && integerOverflowed(operator, leftVal, rightVal, result) {
+			return newError("integer overflow: %d %s %d", leftVal, operator, rightVal)
+		}
+		return &object.Integer{Value: result}
 	case "%":
 		return &object.Integer{Value: leftVal % rightVal}
 	case "&":
@@ -304,10 +357,10 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 		return &object.Integer{Value: leftVal | rightVal}
 	case "^":
 		return &object.Integer{Value: leftVal ^ rightVal}
-	case "<<":
-		return &object.Integer{Value: leftVal << uint(rightVal)}
 	case ">>":
 		return &object.Integer{Value: leftVal >> uint(rightVal)}
+	case "..":
+		return &object.Range{Start: leftVal, Stop: rightVal, Step: 1, Inclusive: true}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case "<=":
@@ -325,6 +378,43 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	}
 }
 
+func integerArithmetic(operator string, a, b int64) int64 {
+	switch operator {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	case "<<":
+		return a << uint(b)
+	}
+	return 0
+}
+
+// integerOverflowed reports whether result, computed with wrapping int64
+// arithmetic, differs from the exact value of a operator b.
+func integerOverflowed(operator string, a, b, result int64) bool {
+	switch operator {
+	case "+":
+		return (a >= 0) == (b >= 0) && (result >= 0) != (a >= 0)
+	case "-":
+		return (a >= 0) != (b >= 0) && (result >= 0) != (a >= 0)
+	case "*":
+		return a != 0 && (result/a != b || (a == -1 && b == math.MinInt64))
+	case "/":
+		return a == math.MinInt64 && b == -1
+	case "<<":
+		if b >= 64 {
+			return a != 0
+		}
+		return result>>uint(b) != a
+	}
+	return false
+}
+
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
@@ -336,11 +426,169 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
+	case "<", "<=", ">", ">=":
+		return nativeBoolToBooleanObject(compareResult(operator, strings.Compare(leftVal, rightVal)))
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+func isNumber(obj object.Object) bool {
+	_, ok := numberValue(obj)
+	return ok
+}
+
+func isComparison(operator string) bool {
+	switch operator {
+	case "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func compareResult(operator string, cmp int) bool {
+	switch operator {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// objectsEqual compares values structurally: numbers by value regardless
+// of INTEGER/FLOAT, arrays element-wise and hashes by their key/value pairs
+// ignoring key order. Functions and other reference types are only equal
+// to themselves.
+func objectsEqual(a, b object.Object) bool {
+	if x, y, ok := integerPair(a, b); ok {
+		return x == y
+	}
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		return ok && x == y
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *object.String:
+		return a.Value == b.(*object.String).Value
+	case *object.Boolean:
+		return a.Value == b.(*object.Boolean).Value
+	case *object.Null:
+		return true
+	case *object.Array:
+		other := b.(*object.Array)
+		if len(a.Elements) != len(other.Elements) {
+			return false
+		}
+		for i, el := range a.Elements {
+			if !objectsEqual(el, other.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		other := b.(*object.Hash)
+		if a.Len() != other.Len() {
+			return false
+		}
+		for _, k := range a.Keys() {
+			v, _ := a.Get(k)
+			ov, ok := other.Get(k)
+			if !ok || !objectsEqual(v, ov) {
+				return false
+			}
+		}
+		return true
+	case *object.Range:
+		other := b.(*object.Range)
+		return a.Start == other.Start && a.Stop == other.Stop && a.Step == other.Step && a.Inclusive == other.Inclusive
+	}
+	return a == b
+}
+
+// integerPair returns both values when a and b are integers, so they can be
+// compared exactly instead of through float64.
+func integerPair(a, b object.Object) (int64, int64, bool) {
+	x, ok := a.(*object.Integer)
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := b.(*object.Integer)
+	if !ok {
+		return 0, 0, false
+	}
+	return x.Value, y.Value, true
+}
+
+// compareObjects orders numbers numerically, strings lexicographically,
+// booleans false before true and arrays element by element. ok is false
+// when the two values have no defined order.
+func compareObjects(a, b object.Object) (cmp int, ok bool) {
+	if x, y, ok := integerPair(a, b); ok {
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if a.Type() != b.Type() {
+		return 0, false
+	}
+
+	switch a := a.(type) {
+	case *object.String:
+		return strings.Compare(a.Value, b.(*object.String).Value), true
+	case *object.Boolean:
+		x, y := a.Value, b.(*object.Boolean).Value
+		switch {
+		case x == y:
+			return 0, true
+		case y:
+			return -1, true
+		}
+		return 1, true
+	case *object.Array:
+		other := b.(*object.Array)
+		for i := 0; i < len(a.Elements) && i < len(other.Elements); i++ {
+			cmp, ok := compareObjects(a.Elements[i], other.Elements[i])
+			if !ok || cmp != 0 {
+				return cmp, ok
+			}
+		}
+		switch {
+		case len(a.Elements) < len(other.Elements):
+			return -1, true
+		case len(a.Elements) > len(other.Elements):
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
@@ -375,6 +623,13 @@ func evalWhileExpression(we *ast.WhileExpression, env *object.Environment) objec
 			if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
 				return result
 			}
+			if result == BREAK {
+				result = NULL
+				break
+			}
+			if result == CONTINUE {
+				result = NULL
+			}
 		}
 	}
 
@@ -413,6 +668,13 @@ func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Ob
 			if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
 				return result
 			}
+			if result == BREAK {
+				result = NULL
+				break
+			}
+			if result == CONTINUE {
+				result = NULL
+			}
 		}
 
 		if fe.Increment != nil {
@@ -435,46 +697,244 @@ func evalForEachExpression(fee *ast.ForEachExpression, env *object.Environment)
 		return iterable
 	}
 
+	next, stop := loopIterator(iterable)
+	if next == nil {
+		return newError("not iterable: %s", iterable.Type())
+	}
+	defer stop()
+
 	var result object.Object
 
-	if array, ok := iterable.(*object.Array); ok {
-		for i, el := range array.Elements {
-			loopEnv := object.NewEnclosedEnvironment(env)
+	for {
+		key, val, ok := next()
+		if !ok {
+			break
+		}
+		if isError(val) {
+			return val
+		}
+		loopEnv := object.NewEnclosedEnvironment(env)
+
+		if fee.KeyVar != "" {
+			loopEnv.Set(fee.KeyVar, key)
+		}
+		if errObj := bindLoopValue(fee, loopEnv, val); errObj != nil {
+			return errObj
+		}
 
-			if fee.KeyVar != "" {
-				loopEnv.Set(fee.KeyVar, &object.Integer{Value: int64(i)})
-			}
-			loopEnv.Set(fee.ValueVar, el)
+		result = Eval(fee.Body, loopEnv)
 
-			result = Eval(fee.Body, loopEnv)
+		if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
+			return result
+		}
+		if result == BREAK {
+			result = NULL
+			break
+		}
+		if result == CONTINUE {
+			result = NULL
+		}
+	}
 
-			if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
-				return result
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+// loopIterator returns the key/value pairs foreach walks over, or nil if
+// obj cannot be iterated. Arrays and strings are keyed by position, hashes
+// by key and other iterables by a running count. stop releases sources
+// that hold a file or cursor open.
+func loopIterator(obj object.Object) (next func() (key, val object.Object, ok bool), stop func()) {
+	stop = func() {}
+	i := -1
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		elements := obj.Elements
+		next = func() (object.Object, object.Object, bool) {
+			i++
+			if i >= len(elements) {
+				return nil, nil, false
+			}
+			return &object.Integer{Value: int64(i)}, elements[i], true
+		}
+	case *object.String:
+		runes := []rune(obj.Value)
+		next = func() (object.Object, object.Object, bool) {
+			i++
+			if i >= len(runes) {
+				return nil, nil, false
 			}
+			return &object.Integer{Value: int64(i)}, &object.String{Value: string(runes[i])}, true
 		}
-	} else if hash, ok := iterable.(*object.Hash); ok {
-		for k, v := range hash.Pairs {
-			loopEnv := object.NewEnclosedEnvironment(env)
+	case *object.Hash:
+		keys := obj.Keys()
+		next = func() (object.Object, object.Object, bool) {
+			for i+1 < len(keys) {
+				i++
+				if v, ok := obj.Get(keys[i]); ok {
+					return &object.String{Value: keys[i]}, v, true
+				}
+			}
+			return nil, nil, false
+		}
+	case object.Iterable:
+		iter := obj.Iterate()
+		if closer, ok := iter.(io.Closer); ok {
+			stop = func() { closer.Close() }
+		}
+		next = func() (object.Object, object.Object, bool) {
+			val, ok := iter.Next()
+			if !ok {
+				return nil, nil, false
+			}
+			i++
+			return &object.Integer{Value: int64(i)}, val, true
+		}
+	}
+	return next, stop
+}
+
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
 
-			if fee.KeyVar != "" {
-				loopEnv.Set(fee.KeyVar, &object.String{Value: k})
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		matched, errObj := matchPattern(arm.Pattern, subject, armEnv)
+		if errObj != nil {
+			return errObj
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
 			}
-			loopEnv.Set(fee.ValueVar, v)
+		}
+		result := Eval(arm.Body, armEnv)
+		if result == nil {
+			return NULL
+		}
+		return result
+	}
+
+	return newError("no match arm for %s", subject.Inspect())
+}
 
-			result = Eval(fee.Body, loopEnv)
+// matchPattern reports whether val fits pattern, binding identifiers into
+// env as it goes. `_` matches anything without binding.
+func matchPattern(pattern ast.Expression, val object.Object, env *object.Environment) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			env.Set(pattern.Value, val)
+		}
+		return true, nil
 
-			if result != nil && (result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ) {
-				return result
+	case *ast.RangePattern:
+		low := Eval(pattern.Low, env)
+		if isError(low) {
+			return false, low
+		}
+		high := Eval(pattern.High, env)
+		if isError(high) {
+			return false, high
+		}
+		n, ok := numberValue(val)
+		lo, okLow := numberValue(low)
+		hi, okHigh := numberValue(high)
+		if !okLow || !okHigh {
+			return false, newError("range pattern bounds must be numbers, got %s..%s", low.Type(), high.Type())
+		}
+		return ok && lo <= n && n <= hi, nil
+
+	case *ast.ArrayPattern:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+		if len(array.Elements) < len(pattern.Elements) || (pattern.Rest == nil && len(array.Elements) != len(pattern.Elements)) {
+			return false, nil
+		}
+		for i, el := range pattern.Elements {
+			if matched, errObj := matchPattern(el.Target, array.Elements[i], env); !matched || errObj != nil {
+				return false, errObj
 			}
 		}
-	} else {
-		return newError("not iterable: %s", iterable.Type())
+		if pattern.Rest != nil {
+			rest := append([]object.Object{}, array.Elements[len(pattern.Elements):]...)
+			env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+		return true, nil
+
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+		used := make(map[string]bool, len(pattern.Entries))
+		for _, entry := range pattern.Entries {
+			used[entry.Key] = true
+			item, exists := hash.Get(entry.Key)
+			if !exists {
+				if entry.Default == nil {
+					return false, nil
+				}
+				item = Eval(entry.Default, env)
+				if isError(item) {
+					return false, item
+				}
+			}
+			if matched, errObj := matchPattern(entry.Target, item, env); !matched || errObj != nil {
+				return false, errObj
+			}
+		}
+		if pattern.Rest != nil {
+			rest := object.NewHash()
+			for _, k := range hash.Keys() {
+				if !used[k] {
+					val, _ := hash.Get(k)
+					rest.Set(k, val)
+				}
+			}
+			env.Set(pattern.Rest.Value, rest)
+		}
+		return true, nil
 	}
 
-	if result == nil {
-		return NULL
+	literal := Eval(pattern, env)
+	if isError(literal) {
+		return false, literal
 	}
-	return result
+	return objectsEqual(literal, val), nil
+}
+
+func numberValue(obj object.Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value), true
+	case *object.Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+func bindLoopValue(fee *ast.ForEachExpression, env *object.Environment, val object.Object) object.Object {
+	if fee.ValuePattern != nil {
+		return bindPattern(fee.ValuePattern, val, env)
+	}
+	env.Set(fee.ValueVar, val)
+	return nil
 }
 
 func isTruthy(obj object.Object) bool {
@@ -502,88 +962,584 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	return newError("identifier not found: %s", node.Value)
 }
 
-func evalPropertyAccessExpression(node *ast.PropertyAccessExpression, env *object.Environment) object.Object {
+// chainSkipped is returned inside an a?.b.c chain once an optional link
+// finds NULL, so the remaining links are skipped. endChain turns it back
+// into NULL where the chain ends. It has its own type because pointers to
+// the empty object.Null struct may compare equal to NULL.
+type skippedChain struct{}
 
-	if leftIdent, ok := node.Left.(*ast.Identifier); ok {
-		methodName := leftIdent.Value + "." + node.Right.Value
-		if builtin, ok := builtins[methodName]; ok {
-			return builtin
-		}
-	}
+func (skippedChain) Type() object.ObjectType { return object.NULL_OBJ }
+func (skippedChain) Inspect() string         { return "null" }
 
-	left := Eval(node.Left, env)
-	if isError(left) {
-		return left
-	}
+var chainSkipped object.Object = skippedChain{}
 
-	if hash, ok := left.(*object.Hash); ok {
-		if val, exists := hash.Pairs[node.Right.Value]; exists {
-			return val
-		}
+func endChain(obj object.Object) object.Object {
+	if obj == chainSkipped {
 		return NULL
 	}
-
-	return newError("property access not supported on %s", left.Type())
+	return obj
 }
 
-func evalIndexExpression(left, index object.Object) object.Object {
-	switch {
-	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
-		return evalArrayIndexExpression(left, index)
-	case left.Type() == object.HASH_OBJ:
-		return evalHashIndexExpression(left, index)
+// evalChainReceiver evaluates the left side of a property, index or call
+// link without ending the chain.
+func evalChainReceiver(exp ast.Expression, env *object.Environment) object.Object {
+	var result object.Object
+	switch exp := exp.(type) {
+	case *ast.PropertyAccessExpression:
+		result = evalPropertyAccessExpression(exp, env)
+	case *ast.IndexExpression:
+		result = evalIndexNode(exp, env)
+	case *ast.SliceExpression:
+		result = evalSliceNode(exp, env)
+	case *ast.CallExpression:
+		result = evalCallExpression(exp, env)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return Eval(exp, env)
+	}
+	if errObj, ok := result.(*object.Error); ok {
+		errObj.Locate(exp.Pos())
 	}
+	return result
 }
 
-func evalArrayIndexExpression(array, index object.Object) object.Object {
-	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
-
-	if idx < 0 || idx > max {
-		return NULL
+func evalCallExpression(node *ast.CallExpression, env *object.Environment) object.Object {
+	function := evalChainReceiver(node.Function, env)
+	if isError(function) || function == chainSkipped {
+		return function
 	}
-
-	return arrayObject.Elements[idx]
+	args, named, errObj := evalCallArguments(node, function, env)
+	if errObj != nil {
+		return errObj
+	}
+	return applyCall(env, function, args, named)
 }
 
-func evalHashIndexExpression(hash, index object.Object) object.Object {
-	hashObject := hash.(*object.Hash)
-	key, ok := index.(*object.String)
-	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+func evalIndexNode(node *ast.IndexExpression, env *object.Environment) object.Object {
+	left := evalChainReceiver(node.Left, env)
+	if isError(left) || left == chainSkipped {
+		return left
 	}
-
-	pair, ok := hashObject.Pairs[key.Value]
-	if !ok {
-		return NULL
+	if node.Optional && left.Type() == object.NULL_OBJ {
+		return chainSkipped
 	}
-
-	return pair
+	index := Eval(node.Index, env)
+	if isError(index) {
+		return index
+	}
+	return evalIndexExpression(left, index)
 }
 
-func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
-	var result []object.Object
+// generatorStopped is what yield evaluates to once the consumer has stopped
+// early. It reports ERROR_OBJ so that lets, assignments, call arguments and
+// blocks pass it upward like an error, but it is not an *object.Error, so
+// try/catch does not catch it and the body unwinds to its end.
+type generatorStopped struct{}
 
-	for _, e := range exps {
-		evaluated := Eval(e, env)
-		if isError(evaluated) {
-			return []object.Object{evaluated}
-		}
-		result = append(result, evaluated)
-	}
+func (generatorStopped) Type() object.ObjectType { return object.ERROR_OBJ }
+func (generatorStopped) Inspect() string         { return "generator stopped" }
 
-	return result
-}
+var stopGenerator object.Object = generatorStopped{}
 
-func applyFunction(env *object.Environment, fn object.Object, args []object.Object) object.Object {
+// evalYieldExpression hands a value to the generator's consumer. When the
+// consumer stops early it returns stopGenerator.
+func evalYieldExpression(node *ast.YieldExpression, env *object.Environment) object.Object {
+	gen := env.Generator()
+	if gen == nil {
+		return newError("yield outside of generator")
+	}
+
+	var val object.Object = NULL
+	if node.Value != nil {
+		val = Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+	}
+	if !gen.Yield(val) {
+		return stopGenerator
+	}
+	return NULL
+}
+
+func evalSliceNode(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := evalChainReceiver(node.Left, env)
+	if isError(left) || left == chainSkipped {
+		return left
+	}
+	if node.Optional && left.Type() == object.NULL_OBJ {
+		return chainSkipped
+	}
+	var bounds [2]object.Object
+	for i, exp := range []ast.Expression{node.Low, node.High} {
+		if exp == nil {
+			continue
+		}
+		bounds[i] = Eval(exp, env)
+		if isError(bounds[i]) {
+			return bounds[i]
+		}
+	}
+	return evalSliceExpression(left, bounds[0], bounds[1])
+}
+
+func evalPropertyAccessExpression(node *ast.PropertyAccessExpression, env *object.Environment) object.Object {
+
+	if leftIdent, ok := node.Left.(*ast.Identifier); ok {
+		if _, shadowed := env.Get(leftIdent.Value); !shadowed {
+			methodName := leftIdent.Value + "." + node.Right.Value
+			if builtin, ok := builtins[methodName]; ok {
+				return builtin
+			}
+		}
+	}
+
+	left := evalChainReceiver(node.Left, env)
+	if isError(left) || left == chainSkipped {
+		return left
+	}
+	if node.Optional && left.Type() == object.NULL_OBJ {
+		return chainSkipped
+	}
+
+	if hash, ok := left.(*object.Hash); ok {
+		if val, exists := hash.Get(node.Right.Value); exists {
+			return val
+		}
+		if method := boundMethod(left, node.Right.Value); method != nil {
+			return method
+		}
+		return NULL
+	}
+
+	if method := boundMethod(left, node.Right.Value); method != nil {
+		return method
+	}
+
+	if ch, ok := left.(*object.Channel); ok {
+		if method := channelMethod(ch, node.Right.Value); method != nil {
+			return method
+		}
+		return newError("unknown channel method: %s", node.Right.Value)
+	}
+
+	if iteratorTypes[left.Type()] {
+		return newError("unknown %s method: %s", left.Type(), node.Right.Value)
+	}
+
+	return newError("property access not supported on %s", left.Type())
+}
+
+// methodModules maps a receiver type to the builtin module whose functions
+// can be called on it as methods, e.g. "abc".upper() runs string.upper.
+var methodModules = map[object.ObjectType]string{
+	object.STRING_OBJ: "string",
+	object.ARRAY_OBJ:  "list",
+	object.HASH_OBJ:   "hash",
+
+	object.RANGE_OBJ:     "list",
+	object.STREAM_OBJ:    "list",
+	object.GENERATOR_OBJ: "list",
+}
+
+// Ranges, streams and generators are lazy, so of the list methods they
+// only get the ones that consume any iterable rather than an ARRAY.
+var (
+	iteratorTypes = map[object.ObjectType]bool{
+		object.RANGE_OBJ:     true,
+		object.STREAM_OBJ:    true,
+		object.GENERATOR_OBJ: true,
+	}
+	iteratorMethods = map[string]bool{"take": true, "collect": true}
+)
+
+func boundMethod(receiver object.Object, name string) object.Object {
+	module, ok := methodModules[receiver.Type()]
+	if !ok {
+		return nil
+	}
+	if iteratorTypes[receiver.Type()] && !iteratorMethods[name] {
+		return nil
+	}
+	builtin, ok := builtins[module+"."+name]
+	if !ok {
+		return nil
+	}
+	return &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return builtin.Fn(env, append([]object.Object{receiver}, args...)...)
+		},
+	}
+}
+
+func evalSelectExpression(node *ast.SelectExpression, env *object.Environment) object.Object {
+	channels := make([]*object.Channel, len(node.Cases))
+	for i, c := range node.Cases {
+		obj := Eval(c.Channel, env)
+		if isError(obj) {
+			return obj
+		}
+		ch, ok := obj.(*object.Channel)
+		if !ok {
+			return newError("select case must be CHANNEL, got %s", obj.Type())
+		}
+		channels[i] = ch
+	}
+
+	var expired <-chan time.Time
+	if node.Timeout != nil {
+		obj := Eval(node.Timeout, env)
+		if isError(obj) {
+			return obj
+		}
+		timeout, ok := secondsToDuration(obj)
+		if !ok {
+			return newError("select timeout must be INTEGER or FLOAT seconds, got %s", obj.Type())
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		waits := make([]reflect.SelectCase, 0, len(channels)+1)
+		for i, ch := range channels {
+			waits = append(waits, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch.Changed())})
+
+			msg, ok, closed := ch.TryRecv()
+			if ok || closed {
+				if !ok {
+					msg = NULL
+				}
+				caseEnv := object.NewEnclosedEnvironment(env)
+				caseEnv.Set(node.Cases[i].Var, msg)
+				return selectResult(Eval(node.Cases[i].Body, caseEnv))
+			}
+		}
+
+		if node.Default != nil {
+			return selectResult(Eval(node.Default, env))
+		}
+
+		if expired != nil {
+			waits = append(waits, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(expired)})
+		}
+		if len(waits) == 0 {
+			return newError("select has no channels to wait on")
+		}
+		if chosen, _, _ := reflect.Select(waits); chosen == len(channels) {
+			return selectResult(Eval(node.TimeoutBody, env))
+		}
+	}
+}
+
+func selectResult(result object.Object) object.Object {
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+	for _, part := range node.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+		if str, ok := val.(*object.String); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString(val.Inspect())
+		}
+	}
+	return &object.String{Value: out.String()}
+}
+
+func evalAssignStatement(node *ast.AssignStatement, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		val := evalAssignValue(node, env, func() object.Object {
+			return evalIdentifier(target, env)
+		})
+		if isError(val) {
+			return val
+		}
+		env.Update(target.Value, val)
+
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		val := evalAssignValue(node, env, func() object.Object {
+			return evalIndexExpression(left, index)
+		})
+		if isError(val) {
+			return val
+		}
+		return evalIndexAssignment(left, index, val)
+
+	case *ast.PropertyAccessExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		hash, ok := left.(*object.Hash)
+		if !ok {
+			return newError("property assignment not supported on %s", left.Type())
+		}
+		val := evalAssignValue(node, env, func() object.Object {
+			if current, ok := hash.Get(target.Right.Value); ok {
+				return current
+			}
+			return NULL
+		})
+		if isError(val) {
+			return val
+		}
+		hash.Set(target.Right.Value, val)
+	}
+
+	return nil
+}
+
+// evalAssignValue evaluates the right-hand side of an assignment, combining
+// it with the current value for compound operators like +=.
+func evalAssignValue(node *ast.AssignStatement, env *object.Environment, current func() object.Object) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) || node.Operator == "=" {
+		return val
+	}
+
+	cur := current()
+	if isError(cur) {
+		return cur
+	}
+	return evalInfixExpression(strings.TrimSuffix(node.Operator, "="), cur, val)
+}
+
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		i := idx.Value
+		if i < 0 {
+			i += int64(len(left.Elements))
+		}
+		if i < 0 || i >= int64(len(left.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+		left.Elements[i] = val
+	case *object.Hash:
+		key, ok := index.(*object.String)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key.Value, val)
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+	return nil
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	arrayObject := array.(*object.Array)
+	idx, errObj := resolveIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
+	if errObj != nil {
+		return errObj
+	}
+	if idx < 0 {
+		return NULL
+	}
+
+	return arrayObject.Elements[idx]
+}
+
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, errObj := resolveIndex(index.(*object.Integer).Value, len(runes))
+	if errObj != nil {
+		return errObj
+	}
+	if idx < 0 {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+// resolveIndex counts negative indices from the end. Out-of-range indices
+// resolve to -1, or to an error when sys.strict is on.
+func resolveIndex(idx int64, length int) (int64, object.Object) {
+	i := idx
+	if i < 0 {
+		i += int64(length)
+	}
+	if i < 0 || i >= int64(length) {
+		if strictMode.Load() {
+			return -1, newError("index out of range: %d with length %d", idx, length)
+		}
+		return -1, nil
+	}
+	return i, nil
+}
+
+func evalSliceExpression(left, low, high object.Object) object.Object {
+	var length int
+	var runes []rune
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		runes = []rune(left.Value)
+		length = len(runes)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	start, errObj := sliceBound(low, 0, length)
+	if errObj != nil {
+		return errObj
+	}
+	end, errObj := sliceBound(high, length, length)
+	if errObj != nil {
+		return errObj
+	}
+	if end < start {
+		end = start
+	}
+
+	if arr, ok := left.(*object.Array); ok {
+		elements := make([]object.Object, end-start)
+		copy(elements, arr.Elements[start:end])
+		return &object.Array{Elements: elements}
+	}
+	return &object.String{Value: string(runes[start:end])}
+}
+
+// sliceBound resolves an optional slice bound the way Python does: negative
+// values count from the end and anything out of range is clamped.
+func sliceBound(bound object.Object, def, length int) (int, object.Object) {
+	if bound == nil {
+		return def, nil
+	}
+	i, ok := bound.(*object.Integer)
+	if !ok {
+		return 0, newError("slice index must be INTEGER, got %s", bound.Type())
+	}
+	n := i.Value
+	if n < 0 {
+		n += int64(length)
+	}
+	return int(max(0, min(n, int64(length)))), nil
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+	key, ok := index.(*object.String)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hashObject.Get(key.Value)
+	if !ok {
+		return NULL
+	}
+
+	return pair
+}
+
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+// evalCallArguments splits the arguments of a call to fn into positional
+// values and name: value pairs. Builtins only take positional arguments.
+func evalCallArguments(call *ast.CallExpression, fn object.Object, env *object.Environment) ([]object.Object, *object.Hash, object.Object) {
+	var args []object.Object
+	var named *object.Hash
+
+	for _, e := range call.Arguments {
+		arg, ok := e.(*ast.NamedArgument)
+		if !ok {
+			evaluated := Eval(e, env)
+			if isError(evaluated) {
+				return nil, nil, evaluated
+			}
+			args = append(args, evaluated)
+			continue
+		}
+
+		if _, ok := fn.(*object.Builtin); ok {
+			return nil, nil, newError("builtin `%s` does not accept named arguments", call.Function)
+		}
+		if named == nil {
+			named = object.NewHash()
+		}
+		if _, exists := named.Get(arg.Name); exists {
+			return nil, nil, newError("argument `%s` given twice", arg.Name)
+		}
+		evaluated := Eval(arg.Value, env)
+		if isError(evaluated) {
+			return nil, nil, evaluated
+		}
+		named.Set(arg.Name, evaluated)
+	}
+
+	return args, named, nil
+}
+
+// applyFunction calls fn on behalf of the runtime, e.g. for callbacks.
+// Extra arguments are dropped so callbacks may declare fewer parameters.
+func applyFunction(env *object.Environment, fn object.Object, args []object.Object) object.Object {
+	return invokeFunction(env, fn, args, nil, false)
+}
+
+// applyCall calls fn from a call expression in a script, where passing
+// more arguments than the function declares is an error.
+func applyCall(env *object.Environment, fn object.Object, args []object.Object, named *object.Hash) object.Object {
+	return invokeFunction(env, fn, args, named, true)
+}
+
+func invokeFunction(env *object.Environment, fn object.Object, args []object.Object, named *object.Hash, strict bool) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if fn.Async {
+			return runAsync(env, func() object.Object {
+				return callFunction(fn, args, named, strict)
+			})
+		}
+		return callFunction(fn, args, named, strict)
 
 	case *object.Builtin:
 		return fn.Fn(env, args...)
@@ -593,12 +1549,207 @@ func applyFunction(env *object.Environment, fn object.Object, args []object.Obje
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+func callFunction(fn *object.Function, args []object.Object, named *object.Hash, strict bool) object.Object {
+	extendedEnv, errObj := extendFunctionEnv(fn, args, named, strict)
+	if errObj != nil {
+		return errObj
+	}
+	if fn.Generator {
+		return object.NewGenerator(fn.Name, func(g *object.Generator) object.Object {
+			extendedEnv.SetGenerator(g)
+			evaluated := Eval(fn.Body, extendedEnv)
+			if evaluated == stopGenerator {
+				return NULL
+			}
+			if errObj, ok := evaluated.(*object.Error); ok {
+				errObj.PushFrame(fn.Name)
+			}
+			return unwrapReturnValue(evaluated)
+		})
+	}
+	evaluated := Eval(fn.Body, extendedEnv)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		errObj.PushFrame(fn.Name)
+	}
+	return unwrapReturnValue(evaluated)
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object, named *object.Hash, strict bool) (*object.Environment, object.Object) {
 	env := object.NewEnclosedEnvironment(fn.Env)
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+
+	positional := fn.Parameters
+	var rest *ast.Parameter
+	if n := len(positional); n > 0 && positional[n-1].Rest {
+		rest = positional[n-1]
+		positional = positional[:n-1]
+	}
+
+	given := len(args)
+	var names []string
+	if named != nil {
+		given += named.Len()
+		names = named.Keys()
+	}
+
+	if strict && rest == nil && len(args) > len(positional) {
+		return nil, arityError(fn, given)
+	}
+
+	for _, name := range names {
+		found := false
+		for i, param := range positional {
+			if param.Name == nil || param.Name.Value != name {
+				continue
+			}
+			if i < len(args) {
+				return nil, newError("argument `%s` given twice", name)
+			}
+			found = true
+		}
+		if !found {
+			return nil, newError("unknown argument `%s`", name)
+		}
+	}
+
+	for i, param := range positional {
+		var val, namedVal object.Object
+		if param.Name != nil && named != nil {
+			namedVal, _ = named.Get(param.Name.Value)
+		}
+		switch {
+		case i < len(args):
+			val = args[i]
+		case namedVal != nil:
+			val = namedVal
+		case param.Default != nil:
+			val = Eval(param.Default, env)
+			if isError(val) {
+				return nil, val
+			}
+		default:
+			return nil, arityError(fn, given)
+		}
+
+		if param.Pattern != nil {
+			if errObj := bindPattern(param.Pattern, val, env); errObj != nil {
+				return nil, errObj
+			}
+			continue
+		}
+		env.Set(param.Name.Value, val)
+	}
+
+	if rest != nil {
+		elements := []object.Object{}
+		if len(args) > len(positional) {
+			elements = append(elements, args[len(positional):]...)
+		}
+		env.Set(rest.Name.Value, &object.Array{Elements: elements})
+	}
+
+	return env, nil
+}
+
+// bindPattern destructures val into env according to pattern. Missing
+// elements take their default value, or NULL when none is given.
+func bindPattern(pattern ast.Expression, val object.Object, env *object.Environment) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		env.Set(pattern.Value, val)
+		return nil
+
+	case *ast.ArrayPattern:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return newError("cannot destructure %s as ARRAY", val.Type())
+		}
+		for i, el := range pattern.Elements {
+			var item object.Object
+			if i < len(array.Elements) {
+				item = array.Elements[i]
+			}
+			if errObj := bindPatternElement(el, item, env); errObj != nil {
+				return errObj
+			}
+		}
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if len(array.Elements) > len(pattern.Elements) {
+				rest = append(rest, array.Elements[len(pattern.Elements):]...)
+			}
+			env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+		return nil
+
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return newError("cannot destructure %s as HASH", val.Type())
+		}
+		used := make(map[string]bool, len(pattern.Entries))
+		for _, entry := range pattern.Entries {
+			used[entry.Key] = true
+			item, _ := hash.Get(entry.Key)
+			if errObj := bindPatternElement(entry, item, env); errObj != nil {
+				return errObj
+			}
+		}
+		if pattern.Rest != nil {
+			rest := object.NewHash()
+			for _, k := range hash.Keys() {
+				if !used[k] {
+					val, _ := hash.Get(k)
+					rest.Set(k, val)
+				}
+			}
+			env.Set(pattern.Rest.Value, rest)
+		}
+		return nil
+	}
+
+	return newError("invalid destructuring pattern: %s", pattern.String())
+}
+
+func bindPatternElement(el *ast.PatternElement, val object.Object, env *object.Environment) object.Object {
+	if val == nil || val == NULL {
+		if el.Default != nil {
+			val = Eval(el.Default, env)
+			if isError(val) {
+				return val
+			}
+		} else {
+			val = NULL
+		}
 	}
-	return env
+	return bindPattern(el.Target, val, env)
+}
+
+func arityError(fn *object.Function, got int) *object.Error {
+	required, optional, variadic := 0, 0, false
+	for _, param := range fn.Parameters {
+		switch {
+		case param.Rest:
+			variadic = true
+		case param.Default != nil:
+			optional++
+		default:
+			required++
+		}
+	}
+
+	want := fmt.Sprintf("%d", required)
+	switch {
+	case variadic:
+		want += "+"
+	case optional > 0:
+		want = fmt.Sprintf("%d to %d", required, required+optional)
+	}
+
+	name := fn.Name
+	if name == "" {
+		name = "function"
+	}
+	return newError("wrong number of arguments to `%s`. got=%d, want=%s", name, got, want)
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -616,9 +1767,9 @@ func isError(obj object.Object) bool {
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[string]object.Object)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
@@ -629,29 +1780,36 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		val := Eval(valueNode, env)
+		val := Eval(node.Pairs[keyNode], env)
 		if isError(val) {
 			return val
 		}
 
-		pairs[keyStr.Value] = val
+		hash.Set(keyStr.Value, val)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalTryCatchExpression(tce *ast.TryCatchExpression, env *object.Environment) object.Object {
 	result := Eval(tce.TryBody, env)
 
-	if result != nil && result.Type() == object.ERROR_OBJ {
-		errObj := result.(*object.Error)
+	if errObj, ok := result.(*object.Error); ok {
 
-		errorMap := &object.Hash{
-			Pairs: map[string]object.Object{
-				"message": &object.String{Value: errObj.Message},
-			},
+		stack := make([]object.Object, len(errObj.Stack))
+		for i, frame := range errObj.Stack {
+			hash := object.NewHash()
+			hash.Set("function", &object.String{Value: frame.Function})
+			hash.Set("file", &object.String{Value: frame.Position.File})
+			hash.Set("line", &object.Integer{Value: int64(frame.Position.Line)})
+			hash.Set("column", &object.Integer{Value: int64(frame.Position.Column)})
+			stack[i] = hash
 		}
 
+		errorMap := object.NewHash()
+		errorMap.Set("message", &object.String{Value: errObj.Message})
+		errorMap.Set("stack", &object.Array{Elements: stack})
+
 		catchEnv := object.NewEnclosedEnvironment(env)
 		catchEnv.Set(tce.CatchVar, errorMap)
 		return Eval(tce.CatchBody, catchEnv)
@@ -698,9 +1856,9 @@ func evalSpawnStatement(node *ast.SpawnStatement, env *object.Environment) objec
 		return fn
 	}
 
-	args := evalExpressions(node.Call.Arguments, env)
-	if len(args) == 1 && isError(args[0]) {
-		return args[0]
+	args, named, errObj := evalCallArguments(node.Call, fn, env)
+	if errObj != nil {
+		return errObj
 	}
 
 	rootEnv := env.Root()
@@ -708,12 +1866,91 @@ func evalSpawnStatement(node *ast.SpawnStatement, env *object.Environment) objec
 
 	go func() {
 		defer rootEnv.Done()
-		applyFunction(env, fn, args)
+		applyCall(env, fn, args, named)
 	}()
 
 	return nil
 }
 
+func evalScheduleStatement(node *ast.ScheduleStatement, env *object.Environment) object.Object {
+	spec := Eval(node.Spec, env)
+	if isError(spec) {
+		return spec
+	}
+	specStr, ok := spec.(*object.String)
+	if !ok {
+		return newError("schedule spec must be STRING, got %s", spec.Type())
+	}
+
+	name := ""
+	if node.Name != nil {
+		name = node.Name.Value
+	}
+	fn := &object.Function{Body: node.Body, Env: env, Name: name}
+
+	return scheduleJob(env, name, specStr.Value, fn)
+}
+
+func evalAsyncExpression(node *ast.AsyncExpression, env *object.Environment) object.Object {
+	fn := Eval(node.Call.Function, env)
+	if isError(fn) {
+		return fn
+	}
+
+	args, named, errObj := evalCallArguments(node.Call, fn, env)
+	if errObj != nil {
+		return errObj
+	}
+
+	return runAsync(env, func() object.Object {
+		return applyCall(env, fn, args, named)
+	})
+}
+
+func runAsync(env *object.Environment, task func() object.Object) *object.Future {
+	future := object.NewFuture()
+
+	rootEnv := env.Root()
+	rootEnv.Add(1)
+
+	go func() {
+		defer rootEnv.Done()
+		result := task()
+		if inner, ok := result.(*object.Future); ok {
+			result = inner.Wait()
+		}
+		if result == nil {
+			result = NULL
+		}
+		future.Resolve(result)
+	}()
+
+	return future
+}
+
+func evalAwaitExpression(node *ast.AwaitExpression, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	future, ok := val.(*object.Future)
+	if !ok {
+		return val
+	}
+	return awaitFuture(future)
+}
+
+func awaitFuture(future *object.Future) object.Object {
+	result := future.Wait()
+	if errObj, ok := result.(*object.Error); ok {
+		copied := *errObj
+		copied.Stack = append([]object.StackFrame(nil), errObj.Stack...)
+		return &copied
+	}
+	return result
+}
+
 func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
@@ -4,7 +4,26 @@ import (
 	"base/lexer"
 	"base/object"
 	"base/parser"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -79,6 +98,26 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestErrorPositions(t *testing.T) {
+	input := `let a = 1;
+let b = a + usr;`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: usr" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+	if errObj.Position.Line != 2 || errObj.Position.Column != 13 {
+		t.Errorf("wrong error position. got=%s", errObj.Position)
+	}
+	if errObj.Inspect() != "ERROR: 2:13: identifier not found: usr" {
+		t.Errorf("wrong inspect output. got=%q", errObj.Inspect())
+	}
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -88,6 +127,16 @@ func testEval(input string) object.Object {
 	return Eval(program, env)
 }
 
+// testInspect evaluates input and compares the result's Inspect output.
+func testInspect(t *testing.T, input string, expected string) bool {
+	evaluated := testEval(input)
+	if evaluated == nil || evaluated.Inspect() != expected {
+		t.Errorf("wrong result for %q. got=%v, want=%s", input, evaluated, expected)
+		return false
+	}
+	return true
+}
+
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	result, ok := obj.(*object.Integer)
 	if !ok {
@@ -100,3 +149,1036 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	}
 	return true
 }
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let inner = function() {
+  throw "boom";
+};
+let outer = function() {
+  inner();
+};
+outer();`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		function string
+		line     int
+	}{
+		{"inner", 2},
+		{"outer", 5},
+		{"<main>", 7},
+	}
+
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong number of frames. got=%d, want=%d", len(errObj.Stack), len(expected))
+	}
+	for i, tt := range expected {
+		frame := errObj.Stack[i]
+		if frame.Function != tt.function || frame.Position.Line != tt.line {
+			t.Errorf("frame %d wrong. got=%s at line %d, want=%s at line %d",
+				i, frame.Function, frame.Position.Line, tt.function, tt.line)
+		}
+	}
+}
+
+func TestCatchExposesStack(t *testing.T) {
+	input := `let fail = function() { throw "bad"; };
+let frames = 0;
+try { fail(); } catch (err) { frames = err.stack; };
+frames;`
+
+	evaluated := testEval(input)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("err.stack is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(arr.Elements) != 1 {
+		t.Fatalf("wrong number of frames. got=%d", len(arr.Elements))
+	}
+	frame := arr.Elements[0].(*object.Hash)
+	if fn, _ := frame.Get("function"); fn.Inspect() != "fail" {
+		t.Errorf("wrong function name. got=%s", fn.Inspect())
+	}
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let i = 0; while (true) { i = i + 1; if i == 5 { break; } }; i;", 5},
+		{"let sum = 0; let i = 0; while (i < 10) { i = i + 1; if i % 2 == 0 { continue; } sum = sum + i; }; sum;", 25},
+		{"let sum = 0; for (let i = 0; i < 10; i = i + 1) { if i == 3 { continue; } if i == 6 { break; } sum = sum + i; }; sum;", 12},
+		{"let sum = 0; foreach x in [1, 2, 3, 4, 5] { if x == 2 { continue; } if x == 5 { break; } sum = sum + x; }; sum;", 8},
+		{"let sum = 0; foreach k, v in {\"a\": 1} { sum = sum + v; break; }; sum;", 1},
+		{"let n = 0; foreach x in [1, 2, 3] { foreach y in [1, 2, 3] { if y == 2 { break; } n = n + 1; } }; n;", 3},
+		{"let n = 0; while (true) { try { n = n + 1; break; } catch (e) { n = 100; } }; n;", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestAsyncAwait(t *testing.T) {
+	RegisterAsyncBuiltins()
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let double = async function(x) { return x * 2; }; await double(21);", 42},
+		{"let add = function(a, b) { a + b; }; let f = async add(1, 2); await f;", 3},
+		{"await 7;", 7},
+		{"let sq = async function(x) { x * x; }; let r = await_all([sq(2), sq(3)]); r[0] + r[1];", 13},
+		{"let sq = async function(x) { x * x; }; await_any(sq(4));", 16},
+		{"async function fetchit(x) { return x + 1; } await fetchit(1);", 2},
+		{"async function() { 5; }; await async function() { 6; }();", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestAwaitSurfacesErrors(t *testing.T) {
+	input := `let fail = async function() { throw "async boom"; };
+let f = fail();
+let msg = "";
+try { await f; } catch (e) { msg = e.message; };
+msg;`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "async boom" {
+		t.Errorf("wrong message. got=%q", str.Value)
+	}
+}
+
+func TestScheduledJobs(t *testing.T) {
+	RegisterScheduleBuiltins()
+
+	input := `schedule "0 0 1 1 *" as yearly { print("new year"); }
+let named = schedule("*/10 * * * * *", function() {}, {"name": "ticker"});
+schedule.pause("ticker");
+let paused = schedule.next("ticker");
+schedule.resume("ticker");
+schedule.remove("yearly");
+[named, paused, schedule.list()];`
+
+	evaluated := testEval(input)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if arr.Elements[0].Inspect() != "ticker" {
+		t.Errorf("wrong job name. got=%s", arr.Elements[0].Inspect())
+	}
+	if arr.Elements[1] != NULL {
+		t.Errorf("paused job should have no next run. got=%s", arr.Elements[1].Inspect())
+	}
+	jobs, ok := arr.Elements[2].(*object.Array)
+	if !ok || len(jobs.Elements) != 1 {
+		t.Fatalf("expected one remaining job. got=%s", arr.Elements[2].Inspect())
+	}
+	name, _ := jobs.Elements[0].(*object.Hash).Get("name")
+	if name.Inspect() != "ticker" {
+		t.Errorf("wrong remaining job. got=%s", name.Inspect())
+	}
+	job := jobs.Elements[0].(*object.Hash)
+	if keys := strings.Join(job.Keys(), ","); keys != "name,spec,paused,next" {
+		t.Errorf("job keys out of insertion order. got=%s", keys)
+	}
+
+	errObj, ok := testEval(`schedule.remove("missing");`).(*object.Error)
+	if !ok || errObj.Message != "no scheduled job named `missing`" {
+		t.Errorf("expected missing job error. got=%v", errObj)
+	}
+	testEval(`schedule.remove("ticker");`)
+}
+
+func TestChannels(t *testing.T) {
+	RegisterChannelBuiltins()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let ch = chan(2);
+spawn function() {
+	let i = 1;
+	while (i <= 5) { ch.send(i); i = i + 1; }
+	ch.close();
+}();
+let sum = 0;
+foreach v in ch { sum = sum + v; }
+sum;`, 15},
+		{"let ch = chan(); ch.send(1); ch.send(2); ch.recv() + ch.len();", 2},
+		{"chan().recv(0.01);", nil},
+		{"let a = chan(); let b = chan(); b.send(7); select { x in a { x; } y in b { y * 2; } };", 14},
+		{"let a = chan(); select { x in a { 1; } after 0.01 { 2; } };", 2},
+		{"let a = chan(); select { x in a { 1; } else { 3; } };", 3},
+		{"let a = chan(); a.close(); select { x in a { x; } };", nil},
+		{"let a = chan(); a.close(); a.send(1);", "send on closed channel"},
+		{"chan(0);", "argument to `chan` must be a positive INTEGER; use chan() for an unbounded channel"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("expected error %q. got=%v", expected, evaluated)
+			}
+		default:
+			if evaluated != NULL {
+				t.Errorf("object is not NULL. got=%T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestServerApp(t *testing.T) {
+	RegisterServerBuiltins()
+
+	input := `let app = server.app();
+app.use(function(req, res, next) { res.header("X-Trace", "on"); next(); });
+app.get("/users/:id", function(req, res) { res.send(200, {"id": req.params.id}); });
+let api = app.group("/api");
+api.use(function(req, res, next) {
+	if (req.headers["Authorization"] == "secret") { next(); } else { res.send(401, {"error": "denied"}); }
+});
+api.post("/items", function(req, res) { res.send(201, req.body); });
+app.listen(0);`
+
+	port, ok := testEval(input).(*object.Integer)
+	if !ok {
+		t.Fatalf("app.listen did not return a port")
+	}
+	t.Cleanup(func() { testEval(fmt.Sprintf("server.stop(%d);", port.Value)) })
+	base := fmt.Sprintf("http://127.0.0.1:%d", port.Value)
+
+	tests := []struct {
+		method string
+		path   string
+		auth   string
+		status int
+		body   string
+	}{
+		{"GET", "/users/42", "", 200, `{"id":"42"}`},
+		{"POST", "/api/items", "", 401, `{"error":"denied"}`},
+		{"POST", "/api/items", "secret", 201, `{"name":"disk"}`},
+		{"GET", "/api/items", "secret", 405, ""},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, base+tt.path, strings.NewReader(`{"name":"disk"}`))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %s", tt.method, tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: wrong status. got=%d, want=%d", tt.method, tt.path, resp.StatusCode, tt.status)
+		}
+		if tt.body != "" && string(body) != tt.body {
+			t.Errorf("%s %s: wrong body. got=%s, want=%s", tt.method, tt.path, body, tt.body)
+		}
+		if tt.status != 405 && resp.Header.Get("X-Trace") != "on" {
+			t.Errorf("%s %s: app middleware did not run", tt.method, tt.path)
+		}
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	RegisterServerBuiltins()
+	RegisterChannelBuiltins()
+	RegisterLifecycleBuiltins()
+
+	input := `let events = chan();
+let quit = chan();
+shutdown_timeout(2);
+on_shutdown(function() { events.send("closed"); quit.close(); });
+spawn function() { quit.recv(); events.send("task done"); }();
+let stopped = server.listen(0, "/", function(req, res) { res.send(200, "ok"); });
+let port = server.listen(0, "/", function(req, res) { res.send(200, "ok"); });
+server.stop(stopped);
+[port, events];`
+
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(input)).ParseProgram()
+	result, ok := Eval(program, env).(*object.Array)
+	if !ok {
+		t.Fatalf("unexpected result. got=%v", result)
+	}
+	port := result.Elements[0].(*object.Integer).Value
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", port))
+	if err != nil {
+		t.Fatalf("server not reachable before shutdown: %s", err)
+	}
+	resp.Body.Close()
+
+	Shutdown(env)
+
+	if _, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", port)); err == nil {
+		t.Errorf("server still reachable after shutdown")
+	}
+
+	events := result.Elements[1].(*object.Channel).Items()
+	if len(events) != 2 || events[0].Inspect() != "closed" || events[1].Inspect() != "task done" {
+		t.Errorf("wrong shutdown order. got=%v", events)
+	}
+}
+
+func TestServerStop(t *testing.T) {
+	RegisterServerBuiltins()
+
+	input := `let app = server.app();
+app.get("/a", function(req, res) { res.send(200, "a"); });
+let first = app.listen(0);
+server.stop(first);
+app.get("/b", function(req, res) { res.send(200, "b"); });
+[first, app.listen(0)];`
+
+	result, ok := testEval(input).(*object.Array)
+	if !ok {
+		t.Fatalf("unexpected result. got=%v", result)
+	}
+	first := result.Elements[0].(*object.Integer).Value
+	second := result.Elements[1].(*object.Integer).Value
+
+	if _, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/a", first)); err == nil {
+		t.Errorf("stopped port still reachable")
+	}
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", second, path))
+		if err != nil {
+			t.Fatalf("GET %s failed: %s", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Errorf("GET %s: wrong status. got=%d", path, resp.StatusCode)
+		}
+	}
+
+	errObj, ok := testEval(fmt.Sprintf("server.stop(%d);", first)).(*object.Error)
+	if !ok || errObj.Message != fmt.Sprintf("no server listening on :%d", first) {
+		t.Errorf("expected stopped handle to be gone. got=%v", errObj)
+	}
+	testEval(fmt.Sprintf("server.stop(%d);", second))
+}
+
+func TestServerTLS(t *testing.T) {
+	RegisterServerBuiltins()
+	certFile, keyFile := writeSelfSignedCert(t)
+
+	input := fmt.Sprintf(`[
+	server.listen(0, "/", function(req, res) { res.send(200, "secure"); }, {"cert": %q, "key": %q}),
+	server.listen(0, "/", function(req, res) { res.send(200, "mutual"); }, {"cert": %q, "key": %q, "client_ca": %q})
+];`, certFile, keyFile, certFile, keyFile, certFile)
+
+	ports, ok := testEval(input).(*object.Array)
+	if !ok {
+		t.Fatalf("server.listen did not return ports")
+	}
+
+	pemBytes, _ := os.ReadFile(certFile)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pemBytes)
+	pair, _ := tls.LoadX509KeyPair(certFile, keyFile)
+
+	plain := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	mutual := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{pair}}}}
+
+	url := func(i int) string {
+		return fmt.Sprintf("https://127.0.0.1:%d/", ports.Elements[i].(*object.Integer).Value)
+	}
+
+	resp, err := plain.Get(url(0))
+	if err != nil {
+		t.Fatalf("https request failed: %s", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `"secure"` {
+		t.Errorf("wrong body. got=%s", body)
+	}
+
+	if _, err := plain.Get(url(1)); err == nil {
+		t.Errorf("mTLS server accepted a client without a certificate")
+	}
+	resp, err = mutual.Get(url(1))
+	if err != nil {
+		t.Fatalf("mTLS request failed: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestServerRedirectHTTP(t *testing.T) {
+	RegisterServerBuiltins()
+	certFile, keyFile := writeSelfSignedCert(t)
+	otherCert, otherKey := writeSelfSignedCert(t)
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	handler := `function(req, res) { res.send(200, "secure"); }`
+	input := fmt.Sprintf(`server.listen(0, "/", %s, {"cert": %q, "key": %q, "redirect_http": %d});`, handler, certFile, keyFile, redirect)
+	port, ok := testEval(input).(*object.Integer)
+	if !ok {
+		t.Fatalf("server.listen did not return a port")
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/users?id=1", redirect))
+	if err != nil {
+		t.Fatalf("plain request failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("wrong status. got=%d, want=%d", resp.StatusCode, http.StatusMovedPermanently)
+	}
+	if location := fmt.Sprintf("https://127.0.0.1:%d/users?id=1", port.Value); resp.Header.Get("Location") != location {
+		t.Errorf("wrong Location. got=%q, want=%q", resp.Header.Get("Location"), location)
+	}
+
+	reuse := fmt.Sprintf(`server.listen(%d, "/same", %s, {"cert": %q, "key": %q});`, port.Value, handler, certFile, keyFile)
+	if result := testEval(reuse); isError(result) {
+		t.Errorf("reusing the port with the same TLS files failed: %s", result.Inspect())
+	}
+
+	other := fmt.Sprintf(`server.listen(%d, "/other", %s, {"cert": %q, "key": %q});`, port.Value, handler, otherCert, otherKey)
+	errObj, ok := testEval(other).(*object.Error)
+	want := fmt.Sprintf("server error: port %d is already serving with different TLS settings", port.Value)
+	if !ok || errObj.Message != want {
+		t.Errorf("expected TLS mismatch error. got=%v", errObj)
+	}
+
+	testEval(fmt.Sprintf("server.stop(%d); server.stop(%d);", port.Value, redirect))
+}
+
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "base-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return certFile, keyFile
+}
+
+func TestServerRequestObject(t *testing.T) {
+	RegisterServerBuiltins()
+
+	input := `server.listen(0, "/", function(req, res) {
+	res.send(200, {
+		"raw": req.raw_body, "form": req.form, "files": req.files,
+		"cookies": req.cookies, "ip": req.ip, "host": req.host
+	});
+}, {"max_body": 1024});`
+
+	port, ok := testEval(input).(*object.Integer)
+	if !ok {
+		t.Fatalf("server.listen did not return a port")
+	}
+	t.Cleanup(func() { testEval(fmt.Sprintf("server.stop(%d);", port.Value)) })
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port.Value)
+
+	post := func(contentType string, body io.Reader) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", url, body)
+		req.Header.Set("Content-Type", contentType)
+		req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		defer resp.Body.Close()
+		var decoded map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+
+	_, got := post("application/x-www-form-urlencoded", strings.NewReader("name=disk&tag=a&tag=b"))
+	if got["raw"] != "name=disk&tag=a&tag=b" || got["ip"] != "127.0.0.1" || got["host"] != fmt.Sprintf("127.0.0.1:%d", port.Value) {
+		t.Errorf("wrong request fields. got=%v", got)
+	}
+	form := got["form"].(map[string]interface{})
+	if form["name"] != "disk" || len(form["tag"].([]interface{})) != 2 {
+		t.Errorf("wrong form. got=%v", form)
+	}
+	if got["cookies"].(map[string]interface{})["session"] != "abc" {
+		t.Errorf("wrong cookies. got=%v", got["cookies"])
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("title", "report")
+	fw, _ := mw.CreateFormFile("upload", "report.txt")
+	fw.Write([]byte("hello"))
+	mw.Close()
+
+	_, got = post(mw.FormDataContentType(), &buf)
+	upload := got["files"].(map[string]interface{})["upload"].(map[string]interface{})
+	if upload["filename"] != "report.txt" || upload["size"] != float64(5) {
+		t.Errorf("wrong upload. got=%v", upload)
+	}
+	if _, err := os.Stat(upload["path"].(string)); !os.IsNotExist(err) {
+		t.Errorf("upload temp file was not removed")
+	}
+	if got["form"].(map[string]interface{})["title"] != "report" {
+		t.Errorf("wrong multipart form. got=%v", got["form"])
+	}
+
+	status, _ := post("text/plain", strings.NewReader(strings.Repeat("x", 2048)))
+	if status != http.StatusRequestEntityTooLarge {
+		t.Errorf("wrong status for large body. got=%d", status)
+	}
+}
+
+func TestAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let xs = [1, 2, 3]; xs[1] = 20; xs[1];", 20},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] + h["b"];`, 3},
+		{`let cfg = {"db": {"port": 5432}}; cfg.db.port = 5433; cfg.db.port;`, 5433},
+		{`let cfg = {"db": {}}; cfg.db.retries = 7; cfg["db"]["retries"];`, 7},
+		{"let n = 10; n += 5; n -= 3; n *= 2; n /= 4; n;", 6},
+		{`let h = {"hits": 1}; h.hits += 1; h["hits"] *= 10; h.hits;`, 20},
+		{"let xs = [1]; xs[0] += 41; xs[0];", 42},
+		{"let xs = [1]; xs[3] = 1;", "index out of range: 3"},
+		{"let s = 1; s.x = 2;", "property assignment not supported on INTEGER"},
+		{"missing += 1;", "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("expected error %q. got=%v", expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	RegisterStdBuiltins()
+	RegisterListBuiltins()
+	RegisterHashBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc".upper();`, "ABC"},
+		{`"a-b".replace("-", "+").upper();`, "A+B"},
+		{"[3, 1, 2].map(function(x) { x * 2; }).filter(function(x) { x > 2; }).sort();", "[4, 6]"},
+		{`{"b": 2, "a": 1}.keys();`, "[b, a]"},
+		{`let h = {"keys": 1}; h.keys;`, "1"},
+		{`let list = [1, 2]; list.length();`, "2"},
+		{`{"a": 1}.merge({"b": 2}).has("b");`, "true"},
+		{`string.upper(s: "x");`, "ERROR: 1:13: builtin `string.upper` does not accept named arguments"},
+		{"[1, 2].map(function(a, b) { a; });", "ERROR: 1:11: wrong number of arguments to `function`. got=1, want=2"},
+		{"[1, 2].filter(function(a, b) { a; });", "ERROR: 1:14: wrong number of arguments to `function`. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let user = {"name": "Ada"}; "Hello ${user.name}!";`, "Hello Ada!"},
+		{`let n = 2; "${n} + ${n} = ${n + n}";`, "2 + 2 = 4"},
+		{`"list: ${[1, 2]}";`, "list: [1, 2]"},
+		{`"tab\there";`, "tab\there"},
+		{"`SELECT *\nFROM t`;", "SELECT *\nFROM t"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+		}
+		if str.Value != tt.expected {
+			t.Errorf("wrong string. got=%q, want=%q", str.Value, tt.expected)
+		}
+	}
+
+	errObj, ok := testEval(`"Hi ${missing}";`).(*object.Error)
+	if !ok || errObj.Inspect() != "ERROR: 1:7: identifier not found: missing" {
+		t.Errorf("wrong interpolation error. got=%v", errObj)
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = function(a, b = 10) { a + b; }; f(1);", 11},
+		{"let f = function(a, b = a * 2) { a + b; }; f(3);", 9},
+		{"let f = function(a, b = 10) { a + b; }; f(1, 2);", 3},
+		{"let f = function(first, ...rest) { rest[1]; }; f(1, 2, 3);", 3},
+		{"let f = function(...items) { items; }; f();", "[]"},
+		{"let connect = function(host, port = 80) { port; }; connect(port: 5432, host: 1);", 5432},
+		{"let f = function(a, b = 2, c = 3) { a * 100 + b * 10 + c; }; f(1, c: 9);", 129},
+		{"let add = function(a, b) { a + b; }; add(1);", "wrong number of arguments to `add`. got=1, want=2"},
+		{"let add = function(a, b) { a + b; }; add(1, 2, 3);", "wrong number of arguments to `add`. got=3, want=2"},
+		{"let f = function(a, b = 1) { a; }; f();", "wrong number of arguments to `f`. got=0, want=1 to 2"},
+		{"let f = function(a, ...rest) { a; }; f();", "wrong number of arguments to `f`. got=0, want=1+"},
+		{"let f = function(a) { a; }; f(1, a: 2);", "argument `a` given twice"},
+		{"let f = function(a) { a; }; f(b: 2);", "unknown argument `b`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error for %q. got=%q, want=%q", tt.input, errObj.Message, expected)
+				}
+			} else if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. got=%s, want=%s", tt.input, evaluated.Inspect(), expected)
+			}
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let {status, body} = {"status": 200, "body": "ok"}; "${status} ${body}";`, "200 ok"},
+		{"let [first, second, ...rest] = [1, 2, 3, 4]; rest;", "[3, 4]"},
+		{"let [a, b = 5] = [1]; a + b;", "6"},
+		{`let {db: {host, port = 5432}} = {"db": {"host": "x"}}; "${host}:${port}";`, "x:5432"},
+		{`let {name: n, ...others} = {"name": "ada", "age": 36}; others.keys();`, "[age]"},
+		{`let [x, [y, z]] = [1, [2, 3]]; x + y + z;`, "6"},
+		{`let names = ""; foreach {name} in [{"name": "a"}, {"name": "b"}] { names += name; }; names;`, "ab"},
+		{"let total = 0; foreach i, [a, b] in [[1, 2], [3, 4]] { total += i + a * b; }; total;", "15"},
+		{`let f = function({host, port = 80}, [flag]) { "${host}:${port}:${flag}"; }; f({"host": "h"}, [true]);`, "h:80:true"},
+		{"let {a} = [1];", "ERROR: 1:1: cannot destructure ARRAY as HASH"},
+	}
+
+	RegisterHashBuiltins()
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	classify := `let classify = function(v) {
+	match v {
+		200 => "ok",
+		400..499 => "client error",
+		{"type": "push", ref} => "push to " + ref,
+		{"type": "issue", number} if number > 100 => "new issue",
+		[x, y] => x + y,
+		[head, ...tail] => tail,
+		"ping" => { let reply = "pong"; reply },
+		_ => "other"
+	}
+};
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"classify(200)", "ok"},
+		{"classify(404)", "client error"},
+		{"classify(499.5)", "other"},
+		{`classify({"type": "push", "ref": "main"})`, "push to main"},
+		{`classify({"type": "issue", "number": 7})`, "other"},
+		{`classify({"type": "issue", "number": 101})`, "new issue"},
+		{"classify([1, 2])", "3"},
+		{"classify([1, 2, 3])", "[2, 3]"},
+		{`classify("ping")`, "pong"},
+		{"classify(-1)", "other"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, classify+tt.input, tt.expected)
+	}
+
+	errObj, ok := testEval(`match 5 { 1 => "one" }`).(*object.Error)
+	if !ok || errObj.Message != "no match arm for 5" {
+		t.Errorf("expected no match error. got=%v", errObj)
+	}
+}
+
+func TestNullSafetyOperators(t *testing.T) {
+	RegisterStdBuiltins()
+	data := `let data = {"user": {"name": "ada", "tags": ["x"]}, "empty": {}};
+let calls = 0;
+let count = function() { calls += 1; calls; };
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"data?.user?.name", "ada"},
+		{"data.missing?.name", "null"},
+		{"data.missing?.name.first.second", "null"},
+		{"data.missing?.name.upper()", "null"},
+		{"data.user?.tags?[0]", "x"},
+		{"data.empty.tags?[0]", "null"},
+		{`data.missing?["a"]["b"]`, "null"},
+		{`data.missing?.name ?? "anon"`, "anon"},
+		{`data.user.name ?? "anon"`, "ada"},
+		{"false ?? true", "false"},
+		{"1 ?? count(); calls", "0"},
+		{"data.empty.x ?? data.empty.y ?? 3", "3"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, data+tt.input, tt.expected)
+	}
+
+	errObj, ok := testEval(data + "data.missing.name.first").(*object.Error)
+	if !ok || errObj.Message != "property access not supported on NULL" {
+		t.Errorf("expected error without optional chaining. got=%v", errObj)
+	}
+}
+
+func TestOrderedHashes(t *testing.T) {
+	RegisterJSONBuiltins()
+	RegisterHashBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, `{"z": 1, "a": 2, "m": 3}`},
+		{`let h = {"z": 1, "a": 2}; h["b"] = 3; h.z = 4; h`, `{"z": 4, "a": 2, "b": 3}`},
+		{`let h = {"z": 1, "a": 2}; h.delete("z"); h.z = 1; h.keys()`, "[a, z]"},
+		{`let out = ""; foreach k, v in {"c": 1, "b": 2, "a": 3} { out += k; }; out`, "cba"},
+		{`json.stringify({"z": 1, "a": {"y": true, "b": [2]}})`, "{\n  \"z\": 1,\n  \"a\": {\n    \"y\": true,\n    \"b\": [\n      2\n    ]\n  }\n}"},
+		{`json.parse("{\"z\": 1, \"a\": [{\"y\": 2, \"b\": 3}]}")`, `{"z": 1, "a": [{"y": 2, "b": 3}]}`},
+		{`{"b": 1, "a": 2} == {"b": 1, "a": 2}`, "true"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+
+	if _, ok := testEval(`json.parse("{\"a\": 1} x")`).(*object.Error); !ok {
+		t.Errorf("expected error for trailing data in json.parse")
+	}
+}
+
+func TestEqualityAndComparison(t *testing.T) {
+	RegisterListBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, "true"},
+		{`[1, "2"] == [1, 2]`, "false"},
+		{`[1, [2, {"x": 3}]] == [1.0, [2, {"x": 3}]]`, "true"},
+		{`1 == "1"`, "false"},
+		{`{"a": 1} != {"a": 1, "b": 2}`, "true"},
+		{`"apple" < "banana"`, "true"},
+		{`"b" >= "b"`, "true"},
+		{"2.5 <= 2.5", "true"},
+		{"3 >= 2.9", "true"},
+		{"7.5 % 2 == 1.5", "true"},
+		{"[1, 2] < [1, 3]", "true"},
+		{"false < true", "true"},
+		{`["b", "a", "c"].sort()`, "[a, b, c]"},
+		{"[10, 9, 2.5, 100].sort() == [2.5, 9, 10, 100]", "true"},
+		{`[[1, "2"], [1, 2]].contains([1, 2])`, "true"},
+		{`[1, 2].contains("1")`, "false"},
+		{"[9007199254740993] == [9007199254740992]", "false"},
+		{"[9007199254740992] < [9007199254740993]", "true"},
+		{"[9007199254740993, 9007199254740992].sort()", "[9007199254740992, 9007199254740993]"},
+		{"[9007199254740992].contains(9007199254740993)", "false"},
+		{`1 < "a"`, "ERROR: 1:3: type mismatch: INTEGER < STRING"},
+		{`[1, "a"].sort()`, "ERROR: 1:14: cannot compare STRING and INTEGER in `list.sort`"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestArithmeticSafety(t *testing.T) {
+	RegisterStdBuiltins()
+	defer checkOverflow.Store(false)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "ERROR: 1:3: division by zero: 1 / 0"},
+		{"7 % 0", "ERROR: 1:3: division by zero: 7 % 0"},
+		{"1 << -1", "ERROR: 1:3: negative shift count: -1"},
+		{`let msg = ""; try { 10 / 0; } catch (e) { msg = e.message; }; msg`, "division by zero: 10 / 0"},
+		{"9223372036854775807 + 1", "-9223372036854775808"},
+		{`math.overflow("error"); 9223372036854775807 + 1`, "ERROR: 1:45: integer overflow: 9223372036854775807 + 1"},
+		{`math.overflow("error"); let n = 4611686018427387904; n *= 2`, "ERROR: 1:54: integer overflow: 4611686018427387904 * 2"},
+		{`math.overflow("error"); 1 << 62`, "4611686018427387904"},
+		{`math.overflow("error"); 1 << 63`, "ERROR: 1:27: integer overflow: 1 << 63"},
+		{`math.overflow("wrap"); 9223372036854775807 + 1`, "-9223372036854775808"},
+		{"math.floor_div(-7, 2)", "-4"},
+		{"math.mod(-7, 2)", "1"},
+		{"math.mod(7, -2)", "-1"},
+		{"math.mod(-7.5, 2) == 0.5", "true"},
+		{"math.floor_div(7, 0)", "ERROR: 1:15: division by zero in `math.floor_div`"},
+		{`math.overflow("saturate")`, `ERROR: 1:14: unknown overflow mode "saturate", want "wrap" or "error"`},
+	}
+
+	for _, tt := range tests {
+		checkOverflow.Store(false)
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestNumberLiteralsAndFormatting(t *testing.T) {
+	RegisterStdBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0xFF + 0b1010 + 0o17", "280"},
+		{"1_000_000", "1000000"},
+		{"1e-6", "0.000001"},
+		{"1e-7", "1e-7"},
+		{"-2.5e-8", "-2.5e-8"},
+		{"1e100", "1e+100"},
+		{"2.5E+3", "2500"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{`"total: " + 1.5`, "total: 1.5"},
+		{"1e21", "1e+21"},
+		{"1.0 / 0.0", "+Inf"},
+		{"-1.0 / 0.0", "-Inf"},
+		{"0.0 / 0.0", "NaN"},
+		{`number.format(1234567.891, {"decimals": 2, "thousands": ","})`, "1,234,567.89"},
+		{`number.format(-1234567, {"thousands": true})`, "-1,234,567"},
+		{`number.format(5, {"decimals": 2})`, "5.00"},
+		{`number.format(0.1)`, "0.1"},
+		{`number.format(1234.5, {"thousands": "."})`, "1.234.5"},
+		{`number.format(1, {"decimals": -1})`, "ERROR: 1:14: `decimals` must be a non-negative INTEGER, got -1"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestSlicingAndNegativeIndexing(t *testing.T) {
+	RegisterBackendBuiltins()
+	defer strictMode.Store(false)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3][-1]", "3"},
+		{"[1, 2, 3][-4]", "null"},
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[1, 2, 3][2:1]", "[]"},
+		{"[1, 2, 3][-10:10]", "[1, 2, 3]"},
+		{"let xs = [1, 2, 3]; let ys = xs[:]; ys[0] = 9; xs", "[1, 2, 3]"},
+		{"let xs = [1, 2, 3]; xs[-1] = 7; xs", "[1, 2, 7]"},
+		{`"héllo"[1]`, "é"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[2:]`, "llo"},
+		{`"héllo"[:2]`, "hé"},
+		{`"hello"[9]`, "null"},
+		{`let n = 2; "hello"[:n]`, "he"},
+		{`5[1:]`, "ERROR: 1:2: slice operator not supported: INTEGER"},
+		{`[1, 2]["a":]`, "ERROR: 1:7: slice index must be INTEGER, got STRING"},
+		{"sys.strict(true); [1, 2, 3][3]", "ERROR: 1:28: index out of range: 3 with length 3"},
+		{`sys.strict(true); "abc"[-4]`, "ERROR: 1:24: index out of range: -4 with length 3"},
+		{"sys.strict(true); [1, 2, 3][-3]", "1"},
+	}
+
+	for _, tt := range tests {
+		strictMode.Store(false)
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestRangesAndIteration(t *testing.T) {
+	RegisterStdBuiltins()
+	RegisterListBuiltins()
+	RegisterBackendBuiltins()
+	RegisterDBBuiltins()
+	RegisterChannelBuiltins()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, []byte("first\r\nsecond\nthird"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(dir, "events.db")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let out = ""; foreach i in 1..4 { out += "${i} " }; out`, "1 2 3 4 "},
+		{"let n = 3; let sum = 0; foreach i in 0..n-1 { sum += i }; sum", "3"},
+		{`let out = ""; foreach i in range(0, 20, 5) { out += "${i} " }; out`, "0 5 10 15 "},
+		{`let out = ""; foreach i in range(3, 0, -1) { out += "${i} " }; out`, "3 2 1 "},
+		{`let out = ""; foreach i in range(3) { out += "${i} " }; out`, "0 1 2 "},
+		{"range(1, 2, 0)", "ERROR: 1:6: `range` step must not be zero"},
+		{"0..5", "0..5"},
+		{"range(0, 6, 2)", "range(0, 6, 2)"},
+		{"1..5 == 1..5", "true"},
+		{"1..5 == 1..6", "false"},
+		{"range(0, 6, 2) == range(0, 6, 2)", "true"},
+		{"(1..3).collect()", "[1, 2, 3]"},
+		{"(1..10).take(2)", "[1, 2]"},
+		{"(1..4).map(function(x) { x })", "ERROR: 1:7: unknown RANGE method: map"},
+		{`let out = ""; foreach i in 9223372036854775806..9223372036854775807 { out += "${i} " }; out`, "9223372036854775806 9223372036854775807 "},
+		{`let out = ""; foreach i in range(9223372036854775805, 9223372036854775807, 3) { out += "${i} " }; out`, "9223372036854775805 "},
+		{`let out = ""; foreach i, ch in "héy" { out += "${i}${ch} " }; out`, "0h 1é 2y "},
+		{`let c = chan(); c.send(1); c.send(2); c.close(); let out = ""; foreach i, v in c { out += "${i + v} " }; out`, "1 3 "},
+		{`let out = ""; foreach line in file.lines("` + logPath + `") { out += line + " " }; out`, "first second third "},
+		{`let out = ""; foreach line in file.lines("` + logPath + `") { out += line + " "; break }; out`, "first "},
+		{`file.lines("` + filepath.Join(dir, "missing") + `")`, "ERROR: 1:11: could not read file: open " + filepath.Join(dir, "missing") + ": no such file or directory"},
+		{`db.connect("s", "sqlite", "` + dbPath + `")
+		db.exec("s", "CREATE TABLE events (id INTEGER)")
+		db.exec("s", "INSERT INTO events VALUES (1), (2), (3)")
+		let ids = ""
+		foreach row in db.stream("s", "SELECT id FROM events WHERE id > ? ORDER BY id", 1) { ids += "${row.id} " }
+		ids`, "2 3 "},
+		{"foreach x in 5 { x }", "ERROR: 1:1: not iterable: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	RegisterStdBuiltins()
+	RegisterListBuiltins()
+	RegisterChannelBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`function* pages(n) { let page = 1; while page <= n { yield "p${page}"; page += 1 } }
+		let out = ""; foreach p in pages(3) { out += p + " " }; out`, "p1 p2 p3 "},
+		{`let count = function(n) { for (let i = 0; i < n; i += 1) { yield i } }; list.collect(count(3))`, "[0, 1, 2]"},
+		{`function* naturals() { let n = 0; while true { yield n; n += 1 } }; list.take(naturals(), 3)`, "[0, 1, 2]"},
+		{`function* naturals() { let n = 0; while true { yield n; n += 1 } }; naturals().take(2)`, "[0, 1]"},
+		{`let seen = chan()
+		function* g() { foreach i in 1..5 { seen.send(i); yield i } }
+		foreach x in g() { if x == 2 { break } }
+		seen.close(); seen.read_all()`, "[1, 2]"},
+		{`function* g() { yield 1; return 0; yield 2 }; list.collect(g())`, "[1]"},
+		{`let seen = chan()
+		function* g() { let a = yield 1; seen.send("resumed"); yield 2 }
+		foreach x in g() { break }
+		seen.close(); seen.read_all()`, "[]"},
+		{`let seen = chan()
+		function* g() { try { seen.send(yield 1) } catch (e) { seen.send("caught") }; seen.send("after") }
+		list.take(g(), 1); seen.close(); seen.read_all()`, "[]"},
+		{`function* g() { yield 1; throw "boom" }; let last = 0; foreach x in g() { last = x }`, "ERROR: 1:26: boom"},
+		{`function* g() { yield }; list.collect(g())`, "[null]"},
+		{`function* g() { yield 1 }; let it = g(); list.collect(it); list.collect(it)`, "[]"},
+		{`function* g() { yield 1 }; type(g())`, "GENERATOR"},
+		{`function add(a, b) { return a + b }; add(2, 3)`, "5"},
+		{"list.collect(1..3)", "[1, 2, 3]"},
+		{"list.take([1, 2, 3], 5)", "[1, 2, 3]"},
+		{"list.collect(5)", "ERROR: 1:13: argument to `list.collect` must be iterable, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestArrowFunctionsAndPipes(t *testing.T) {
+	RegisterStdBuiltins()
+	RegisterListBuiltins()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let users = [{"email": "a@x", "active": true}, {"email": "b@x", "active": false}]
+		users |> list.filter(u => u.active) |> list.map(u => u.email)`, "[a@x]"},
+		{"let add = (a, b) => a + b; add(2, 3)", "5"},
+		{"let inc = (x, step = 1) => x + step; inc(1) + inc(1, step: 5)", "8"},
+		{"let f = x => { let y = x * 2; return y + 1 }; f(5)", "11"},
+		{"let add = a => b => a + b; add(1)(2)", "3"},
+		{"5 |> (x => x * 2)", "10"},
+		{"1..3 |> list.collect", "[1, 2, 3]"},
+		{`"abc" |> string.upper`, "ABC"},
+		{"[3, 1, 2] |> list.sort == [1, 2, 3]", "true"},
+		{`match 7 { n if n > 5 => "big", _ => "small" }`, "big"},
+		{"let f = false ? x => x + 1 : x => x - 1; f(1)", "0"},
+		{"[[1, 2], [3, 4]] |> list.map(([a, b]) => a + b)", "[3, 7]"},
+		{`let name = ({name}) => name; name({"name": "ada"})`, "ada"},
+		{"let f = (a, b) => a; f(1, 2, 3)", "ERROR: 1:23: wrong number of arguments to `f`. got=3, want=2"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"base/object"
+)
+
+func RegisterHashBuiltins() {
+	builtins["hash.keys"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, errObj := hashArg("hash.keys", 1, args)
+			if errObj != nil {
+				return errObj
+			}
+			keys := hash.Keys()
+			elements := make([]object.Object, len(keys))
+			for i, k := range keys {
+				elements[i] = &object.String{Value: k}
+			}
+			return &object.Array{Elements: elements}
+		},
+	}
+
+	builtins["hash.values"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, errObj := hashArg("hash.values", 1, args)
+			if errObj != nil {
+				return errObj
+			}
+			keys := hash.Keys()
+			elements := make([]object.Object, len(keys))
+			for i, k := range keys {
+				elements[i], _ = hash.Get(k)
+			}
+			return &object.Array{Elements: elements}
+		},
+	}
+
+	builtins["hash.length"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, errObj := hashArg("hash.length", 1, args)
+			if errObj != nil {
+				return errObj
+			}
+			return &object.Integer{Value: int64(hash.Len())}
+		},
+	}
+
+	builtins["hash.has"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, errObj := hashArg("hash.has", 2, args)
+			if errObj != nil {
+				return errObj
+			}
+			key, ok := args[1].(*object.String)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+			_, exists := hash.Get(key.Value)
+			return nativeBoolToBooleanObject(exists)
+		},
+	}
+
+	builtins["hash.delete"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, errObj := hashArg("hash.delete", 2, args)
+			if errObj != nil {
+				return errObj
+			}
+			key, ok := args[1].(*object.String)
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+			val, exists := hash.Delete(key.Value)
+			if !exists {
+				return NULL
+			}
+			return val
+		},
+	}
+
+	builtins["hash.merge"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, errObj := hashArg("hash.merge", 2, args)
+			if errObj != nil {
+				return errObj
+			}
+			other, ok := args[1].(*object.Hash)
+			if !ok {
+				return newError("arguments to `hash.merge` must be (HASH, HASH)")
+			}
+			merged := object.NewHash()
+			for _, k := range hash.Keys() {
+				val, _ := hash.Get(k)
+				merged.Set(k, val)
+			}
+			for _, k := range other.Keys() {
+				val, _ := other.Get(k)
+				merged.Set(k, val)
+			}
+			return merged
+		},
+	}
+}
+
+func hashArg(name string, want int, args []object.Object) (*object.Hash, *object.Error) {
+	if len(args) != want {
+		return nil, newError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, newError("first argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	return hash, nil
+}
@@ -2,10 +2,154 @@ package evaluator
 
 import (
 	"base/object"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
 )
 
+// orderedMap is the Go form of a hash. Unlike a Go map it remembers key
+// order, and keeps it when encoded to JSON or YAML.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) set(key string, val interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = val
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *orderedMap) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, k := range m.keys {
+		var key, val yaml.Node
+		if err := key.Encode(k); err != nil {
+			return nil, err
+		}
+		if err := val.Encode(m.values[k]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &key, &val)
+	}
+	return node, nil
+}
+
+// bsonDocument converts m to a bson.D so MongoDB stores fields in order.
+func (m *orderedMap) bsonDocument() bson.D {
+	doc := make(bson.D, 0, len(m.keys))
+	for _, k := range m.keys {
+		doc = append(doc, bson.E{Key: k, Value: toBSON(m.values[k])})
+	}
+	return doc
+}
+
+func toBSON(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *orderedMap:
+		return v.bsonDocument()
+	case []interface{}:
+		arr := make(bson.A, len(v))
+		for i, el := range v {
+			arr[i] = toBSON(el)
+		}
+		return arr
+	}
+	return val
+}
+
+// decodeJSON is json.Unmarshal into an interface{}, except that objects
+// decode to *orderedMap so their key order survives.
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	val, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return val, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := newOrderedMap()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.set(keyTok.(string), val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	return tok, nil
+}
+
 func baseObjectToGoType(obj object.Object) interface{} {
 	switch o := obj.(type) {
 	case *object.Integer:
@@ -25,11 +169,12 @@ func baseObjectToGoType(obj object.Object) interface{} {
 		}
 		return arr
 	case *object.Hash:
-		hashMap := make(map[string]interface{})
-		for k, v := range o.Pairs {
-			hashMap[k] = baseObjectToGoType(v)
+		m := newOrderedMap()
+		for _, k := range o.Keys() {
+			val, _ := o.Get(k)
+			m.set(k, baseObjectToGoType(val))
 		}
-		return hashMap
+		return m
 	default:
 		return fmt.Sprintf("<unserializable_type:%s>", o.Type())
 	}
@@ -73,12 +218,34 @@ func goTypeToBaseObject(val interface{}) object.Object {
 			elements[i] = goTypeToBaseObject(el)
 		}
 		return &object.Array{Elements: elements}
+	case *orderedMap:
+		hash := object.NewHash()
+		for _, k := range v.keys {
+			hash.Set(k, goTypeToBaseObject(v.values[k]))
+		}
+		return hash
+	case bson.D:
+		hash := object.NewHash()
+		for _, el := range v {
+			hash.Set(el.Key, goTypeToBaseObject(el.Value))
+		}
+		return hash
+	case bson.A:
+		return goTypeToBaseObject([]interface{}(v))
+	case bson.M:
+		return goTypeToBaseObject(map[string]interface{}(v))
 	case map[string]interface{}:
-		pairs := make(map[string]object.Object)
-		for k, el := range v {
-			pairs[k] = goTypeToBaseObject(el)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		hash := object.NewHash()
+		for _, k := range keys {
+			hash.Set(k, goTypeToBaseObject(v[k]))
 		}
-		return &object.Hash{Pairs: pairs}
+		return hash
 	default:
 		return newError("unsupported type in conversion: %T", v)
 	}
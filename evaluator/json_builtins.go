@@ -18,8 +18,7 @@ func RegisterJSONBuiltins() {
 			}
 
 			
-			var result interface{}
-			err := json.Unmarshal([]byte(strObj.Value), &result)
+			result, err := decodeJSON([]byte(strObj.Value))
 			if err != nil {
 				return newError("failed to parse JSON: %s", err.Error())
 			}
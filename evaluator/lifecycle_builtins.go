@@ -0,0 +1,89 @@
+package evaluator
+
+import (
+	"base/object"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	shutdownHooks   []object.Object
+	shutdownTimeout = 10 * time.Second
+	shutdownMu      sync.Mutex
+)
+
+func RegisterLifecycleBuiltins() {
+	builtins["on_shutdown"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			switch args[0].(type) {
+			case *object.Function, *object.Builtin:
+			default:
+				return newError("argument to `on_shutdown` must be FUNCTION, got %s", args[0].Type())
+			}
+
+			shutdownMu.Lock()
+			shutdownHooks = append(shutdownHooks, args[0])
+			shutdownMu.Unlock()
+			return NULL
+		},
+	}
+
+	builtins["shutdown_timeout"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			timeout, ok := secondsToDuration(args[0])
+			if !ok {
+				return newError("argument to `shutdown_timeout` must be INTEGER or FLOAT seconds")
+			}
+
+			shutdownMu.Lock()
+			shutdownTimeout = timeout
+			shutdownMu.Unlock()
+			return NULL
+		},
+	}
+}
+
+// Shutdown drains the running servers, runs the on_shutdown hooks, then
+// stops the scheduler and waits for spawned tasks, giving up once the
+// shutdown timeout elapses. Hooks run before the wait so they can signal
+// long-running workers and cron jobs to finish.
+func Shutdown(env *object.Environment) {
+	shutdownMu.Lock()
+	hooks := append([]object.Object{}, shutdownHooks...)
+	timeout := shutdownTimeout
+	shutdownMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stopAllListeners(ctx)
+
+	for _, hook := range hooks {
+		if errObj, ok := applyFunction(env, hook, []object.Object{}).(*object.Error); ok {
+			fmt.Println(errObj.Inspect())
+		}
+	}
+
+	select {
+	case <-globalCron.Stop().Done():
+	case <-ctx.Done():
+	}
+
+	done := make(chan struct{})
+	go func() {
+		env.Root().Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
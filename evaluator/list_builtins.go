@@ -32,7 +32,11 @@ func RegisterListBuiltins() {
 
 			newElements := make([]object.Object, len(arr.Elements))
 			for i, el := range arr.Elements {
-				newElements[i] = applyFunction(env, fn, []object.Object{el})
+				res := applyFunction(env, fn, []object.Object{el})
+				if isError(res) {
+					return res
+				}
+				newElements[i] = res
 			}
 			return &object.Array{Elements: newElements}
 		},
@@ -52,6 +56,9 @@ func RegisterListBuiltins() {
 			newElements := []object.Object{}
 			for _, el := range arr.Elements {
 				res := applyFunction(env, fn, []object.Object{el})
+				if isError(res) {
+					return res
+				}
 				if res == TRUE {
 					newElements = append(newElements, el)
 				}
@@ -71,7 +78,7 @@ func RegisterListBuiltins() {
 			}
 			target := args[1]
 			for _, el := range arr.Elements {
-				if el.Inspect() == target.Inspect() { 
+				if objectsEqual(el, target) {
 					return TRUE
 				}
 			}
@@ -91,10 +98,64 @@ func RegisterListBuiltins() {
 			
 			newElements := make([]object.Object, len(arr.Elements))
 			copy(newElements, arr.Elements)
-			sort.Slice(newElements, func(i, j int) bool {
-				return newElements[i].Inspect() < newElements[j].Inspect()
+			var errObj *object.Error
+			sort.SliceStable(newElements, func(i, j int) bool {
+				cmp, ok := compareObjects(newElements[i], newElements[j])
+				if !ok && errObj == nil {
+					errObj = newError("cannot compare %s and %s in `list.sort`", newElements[i].Type(), newElements[j].Type())
+				}
+				return cmp < 0
 			})
+			if errObj != nil {
+				return errObj
+			}
 			return &object.Array{Elements: newElements}
 		},
 	}
+
+	builtins["list.take"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			n, ok := args[1].(*object.Integer)
+			if !ok || n.Value < 0 {
+				return newError("second argument to `list.take` must be a non-negative INTEGER")
+			}
+			return collectValues("list.take", args[0], n.Value)
+		},
+	}
+
+	builtins["list.collect"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			return collectValues("list.collect", args[0], -1)
+		},
+	}
+}
+
+// collectValues reads up to limit values (all of them if limit < 0) from
+// anything foreach can iterate. Streams and generators are closed
+// afterwards, so taking a prefix stops the producer.
+func collectValues(name string, src object.Object, limit int64) object.Object {
+	next, stop := loopIterator(src)
+	if next == nil {
+		return newError("argument to `%s` must be iterable, got %s", name, src.Type())
+	}
+	defer stop()
+
+	elements := []object.Object{}
+	for limit < 0 || int64(len(elements)) < limit {
+		_, val, ok := next()
+		if !ok {
+			break
+		}
+		if isError(val) {
+			return val
+		}
+		elements = append(elements, val)
+	}
+	return &object.Array{Elements: elements}
 }
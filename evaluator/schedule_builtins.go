@@ -0,0 +1,220 @@
+package evaluator
+
+import (
+	"base/object"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+var globalCron = cron.New(cron.WithParser(cronParser))
+
+type cronJob struct {
+	name     string
+	spec     string
+	schedule cron.Schedule
+	job      cron.Job
+	id       cron.EntryID
+	paused   bool
+}
+
+var (
+	cronJobs  = map[string]*cronJob{}
+	cronMu    sync.Mutex
+	cronCount int
+)
+
+func RegisterScheduleBuiltins() {
+	globalCron.Start()
+
+	builtins["schedule"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 2 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+			}
+			spec, ok1 := args[0].(*object.String)
+			fn, ok2 := args[1].(*object.Function)
+
+			if !ok1 || !ok2 {
+				return newError("arguments to `schedule` must be (STRING, FUNCTION, HASH?)")
+			}
+
+			name := ""
+			specStr := spec.Value
+			if len(args) == 3 {
+				opts, ok := args[2].(*object.Hash)
+				if !ok {
+					return newError("options to `schedule` must be HASH, got %s", args[2].Type())
+				}
+				n, _ := opts.Get("name")
+				if n, ok := n.(*object.String); ok {
+					name = n.Value
+				}
+				tz, _ := opts.Get("timezone")
+				if tz, ok := tz.(*object.String); ok {
+					if _, err := time.LoadLocation(tz.Value); err != nil {
+						return newError("unknown timezone: %s", tz.Value)
+					}
+					specStr = "CRON_TZ=" + tz.Value + " " + specStr
+				}
+			}
+
+			return scheduleJob(env, name, specStr, fn)
+		},
+	}
+
+	builtins["schedule.list"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			cronMu.Lock()
+			defer cronMu.Unlock()
+
+			names := make([]string, 0, len(cronJobs))
+			for name := range cronJobs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			elements := make([]object.Object, len(names))
+			for i, name := range names {
+				job := cronJobs[name]
+				entry := object.NewHash()
+				entry.Set("name", &object.String{Value: job.name})
+				entry.Set("spec", &object.String{Value: job.spec})
+				entry.Set("paused", nativeBoolToBooleanObject(job.paused))
+				entry.Set("next", nextRun(job))
+				elements[i] = entry
+			}
+			return &object.Array{Elements: elements}
+		},
+	}
+
+	builtins["schedule.next"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			job, errObj := lookupCronJob("schedule.next", args)
+			if errObj != nil {
+				return errObj
+			}
+
+			cronMu.Lock()
+			defer cronMu.Unlock()
+			return nextRun(job)
+		},
+	}
+
+	builtins["schedule.remove"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			job, errObj := lookupCronJob("schedule.remove", args)
+			if errObj != nil {
+				return errObj
+			}
+
+			cronMu.Lock()
+			defer cronMu.Unlock()
+			if !job.paused {
+				globalCron.Remove(job.id)
+			}
+			delete(cronJobs, job.name)
+			return TRUE
+		},
+	}
+
+	builtins["schedule.pause"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			job, errObj := lookupCronJob("schedule.pause", args)
+			if errObj != nil {
+				return errObj
+			}
+
+			cronMu.Lock()
+			defer cronMu.Unlock()
+			if !job.paused {
+				globalCron.Remove(job.id)
+				job.paused = true
+			}
+			return TRUE
+		},
+	}
+
+	builtins["schedule.resume"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			job, errObj := lookupCronJob("schedule.resume", args)
+			if errObj != nil {
+				return errObj
+			}
+
+			cronMu.Lock()
+			defer cronMu.Unlock()
+			if job.paused {
+				job.id = globalCron.Schedule(job.schedule, job.job)
+				job.paused = false
+			}
+			return TRUE
+		},
+	}
+}
+
+func scheduleJob(env *object.Environment, name, spec string, fn *object.Function) object.Object {
+	schedule, err := cronParser.Parse(spec)
+	if err != nil {
+		return newError("cron schedule error: %s", err.Error())
+	}
+
+	cronMu.Lock()
+	defer cronMu.Unlock()
+
+	if name == "" {
+		cronCount++
+		name = fmt.Sprintf("job-%d", cronCount)
+	}
+	if _, exists := cronJobs[name]; exists {
+		return newError("a job named `%s` is already scheduled", name)
+	}
+
+	job := &cronJob{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		job: cron.FuncJob(func() {
+			applyFunction(env, fn, []object.Object{})
+		}),
+	}
+	job.id = globalCron.Schedule(job.schedule, job.job)
+	cronJobs[name] = job
+
+	KeepAlive = true
+	return &object.String{Value: name}
+}
+
+func lookupCronJob(builtin string, args []object.Object) (*cronJob, *object.Error) {
+	if len(args) != 1 {
+		return nil, newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return nil, newError("argument to `%s` must be STRING, got %s", builtin, args[0].Type())
+	}
+
+	cronMu.Lock()
+	defer cronMu.Unlock()
+	job, ok := cronJobs[name.Value]
+	if !ok {
+		return nil, newError("no scheduled job named `%s`", name.Value)
+	}
+	return job, nil
+}
+
+func nextRun(job *cronJob) object.Object {
+	if job.paused {
+		return NULL
+	}
+	return &object.String{Value: job.schedule.Next(time.Now()).Format(time.RFC3339)}
+}
@@ -2,177 +2,90 @@ package evaluator
 
 import (
 	"base/object"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 func RegisterServerBuiltins() {
 	builtins["server.listen"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 3 {
-				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			if len(args) < 3 || len(args) > 4 {
+				return newError("wrong number of arguments. got=%d, want=3 or 4", len(args))
 			}
 			port, ok1 := args[0].(*object.Integer)
 			path, ok2 := args[1].(*object.String)
 			fn, ok3 := args[2].(*object.Function)
 
 			if !ok1 || !ok2 || !ok3 {
-				return newError("arguments to `server.listen` must be (INTEGER, STRING, FUNCTION)")
-			}
-
-			mux := http.NewServeMux()
-
-			handler := func(w http.ResponseWriter, r *http.Request) {
-				body, _ := ioutil.ReadAll(r.Body)
-				var bodyObj interface{}
-				json.Unmarshal(body, &bodyObj)
-
-				queryParams := &object.Hash{Pairs: map[string]object.Object{}}
-				for k, v := range r.URL.Query() {
-					if len(v) == 1 {
-						queryParams.Pairs[k] = &object.String{Value: v[0]}
-					} else {
-						elements := make([]object.Object, len(v))
-						for i, val := range v {
-							elements[i] = &object.String{Value: val}
-						}
-						queryParams.Pairs[k] = &object.Array{Elements: elements}
-					}
-				}
-
-				reqHash := &object.Hash{
-					Pairs: map[string]object.Object{
-						"method":  &object.String{Value: r.Method},
-						"path":    &object.String{Value: r.URL.Path},
-						"query":   queryParams,
-						"headers": goTypeToBaseObject(parseHeaders(r.Header)),
-						"body":    goTypeToBaseObject(bodyObj),
-					},
-				}
-
-				customHeaders := map[string]string{}
-
-				resSend := &object.Builtin{
-					Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
-						if len(innerArgs) < 2 {
-							return newError("res.send needs (status, body)")
-						}
-						status, _ := innerArgs[0].(*object.Integer)
-						for k, v := range customHeaders {
-							w.Header().Set(k, v)
-						}
-						if w.Header().Get("Content-Type") == "" {
-							w.Header().Set("Content-Type", "application/json")
-						}
-						w.WriteHeader(int(status.Value))
-						jsonBytes, _ := json.Marshal(baseObjectToGoType(innerArgs[1]))
-						w.Write(jsonBytes)
-						return NULL
-					},
-				}
-
-				resHtml := &object.Builtin{
-					Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
-						if len(innerArgs) < 2 {
-							return newError("res.html needs (status, content)")
-						}
-						status, _ := innerArgs[0].(*object.Integer)
-						for k, v := range customHeaders {
-							w.Header().Set(k, v)
-						}
-						w.Header().Set("Content-Type", "text/html; charset=utf-8")
-						w.WriteHeader(int(status.Value))
-						w.Write([]byte(innerArgs[1].Inspect()))
-						return NULL
-					},
-				}
-
-				resHeader := &object.Builtin{
-					Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
-						if len(innerArgs) != 2 {
-							return newError("res.header needs (key, value)")
-						}
-						customHeaders[innerArgs[0].Inspect()] = innerArgs[1].Inspect()
-						return NULL
-					},
-				}
-
-				resFile := &object.Builtin{
-					Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
-						if len(innerArgs) < 2 {
-							return newError("res.file needs (status, filepath)")
-						}
-						status, _ := innerArgs[0].(*object.Integer)
-						filePath, _ := innerArgs[1].(*object.String)
-						content, err := ioutil.ReadFile(filePath.Value)
-						if err != nil {
-							w.WriteHeader(404)
-							w.Write([]byte("File not found"))
-							return NULL
-						}
-						ext := filepath.Ext(filePath.Value)
-						mimeType := mime.TypeByExtension(ext)
-						if mimeType == "" {
-							mimeType = "application/octet-stream"
-						}
-						for k, v := range customHeaders {
-							w.Header().Set(k, v)
-						}
-						w.Header().Set("Content-Type", mimeType)
-						w.WriteHeader(int(status.Value))
-						w.Write(content)
-						return NULL
-					},
-				}
-
-				resObj := &object.Hash{
-					Pairs: map[string]object.Object{
-						"send":   resSend,
-						"html":   resHtml,
-						"header": resHeader,
-						"file":   resFile,
-					},
-				}
-
-				applyFunction(env, fn, []object.Object{reqHash, resObj})
+				return newError("arguments to `server.listen` must be (INTEGER, STRING, FUNCTION, HASH?)")
 			}
 
-			mux.HandleFunc(path.Value, handler)
+			pattern, params := routePattern("", path.Value)
+			l, err := listenWithOptions(int(port.Value), args[3:])
+			if err != nil {
+				return newError("server error: %s", err.Error())
+			}
+			err = l.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+				l.serve(w, r, params, func(req, res object.Object) object.Object {
+					return applyFunction(env, fn, []object.Object{req, res})
+				})
+			})
+			if err != nil {
+				return newError("server error: %s", err.Error())
+			}
 
-			go func() {
-				KeepAlive = true
-				fmt.Printf("B.A.S.E. Server listening on :%d%s\n", port.Value, path.Value)
-				http.ListenAndServe(fmt.Sprintf(":%d", port.Value), mux)
-			}()
+			fmt.Printf("B.A.S.E. Server listening on :%d%s\n", l.port, path.Value)
+			return &object.Integer{Value: int64(l.port)}
+		},
+	}
 
-			return TRUE
+	builtins["server.app"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+			return newRouter(env, &httpApp{}, "", nil).object()
 		},
 	}
 
 	builtins["server.static"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			if len(args) < 2 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
 			}
 			port, ok1 := args[0].(*object.Integer)
 			dir, ok2 := args[1].(*object.String)
 
 			if !ok1 || !ok2 {
-				return newError("arguments to `server.static` must be (INTEGER, STRING)")
+				return newError("arguments to `server.static` must be (INTEGER, STRING, HASH?)")
 			}
 
-			mux := http.NewServeMux()
+			l, err := listenWithOptions(int(port.Value), args[2:])
+			if err != nil {
+				return newError("server error: %s", err.Error())
+			}
 			fs := http.FileServer(http.Dir(dir.Value))
 
-			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			err = l.handle("/", func(w http.ResponseWriter, r *http.Request) {
 				fullPath := filepath.Join(dir.Value, filepath.Clean(r.URL.Path))
 
 				info, err := os.Stat(fullPath)
@@ -192,18 +105,703 @@ func RegisterServerBuiltins() {
 
 				fs.ServeHTTP(w, r)
 			})
+			if err != nil {
+				return newError("server error: %s", err.Error())
+			}
 
-			go func() {
-				KeepAlive = true
-				fmt.Printf("B.A.S.E. Static Server on :%d serving %s\n", port.Value, dir.Value)
-				http.ListenAndServe(fmt.Sprintf(":%d", port.Value), mux)
-			}()
+			fmt.Printf("B.A.S.E. Static Server on :%d serving %s\n", l.port, dir.Value)
+			return &object.Integer{Value: int64(l.port)}
+		},
+	}
 
+	// The handle returned by server.listen, server.static and app.listen is
+	// the port, so server.stop closes the shared listener for every route
+	// and app on that port, not just the one that returned the handle.
+	builtins["server.stop"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			port, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("argument to `server.stop` must be INTEGER, got %s", args[0].Type())
+			}
+
+			httpListenersMu.Lock()
+			l, ok := httpListeners[int(port.Value)]
+			delete(httpListeners, int(port.Value))
+			httpListenersMu.Unlock()
+			if !ok {
+				return newError("no server listening on :%d", port.Value)
+			}
+
+			shutdownMu.Lock()
+			timeout := shutdownTimeout
+			shutdownMu.Unlock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := l.server.Shutdown(ctx); err != nil {
+				return newError("server error: %s", err.Error())
+			}
 			return TRUE
 		},
 	}
 }
 
+type httpListener struct {
+	port int
+	// tls names the cert, key and client CA files the port was opened
+	// with, empty for plain HTTP.
+	tls     string
+	maxBody atomic.Int64
+	mux     *http.ServeMux
+	server  *http.Server
+}
+
+var (
+	httpListeners   = map[int]*httpListener{}
+	httpListenersMu sync.Mutex
+)
+
+// listenOn returns the listener for a port, starting one on first use so
+// that every route and static handler on a port shares a single server.
+// tlsFiles identifies tlsConfig; reusing a port with other TLS files fails.
+func listenOn(port int, tlsConfig *tls.Config, tlsFiles string) (*httpListener, error) {
+	httpListenersMu.Lock()
+	defer httpListenersMu.Unlock()
+
+	if l, ok := httpListeners[port]; ok {
+		if l.tls != tlsFiles {
+			return nil, fmt.Errorf("port %d is already serving with different TLS settings", port)
+		}
+		return l, nil
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	mux := http.NewServeMux()
+	l := &httpListener{
+		port:   ln.Addr().(*net.TCPAddr).Port,
+		tls:    tlsFiles,
+		mux:    mux,
+		server: &http.Server{Handler: mux},
+	}
+	httpListeners[l.port] = l
+
+	KeepAlive = true
+	go l.server.Serve(ln)
+
+	return l, nil
+}
+
+// listenWithOptions starts or reuses the listener for a port, applying the
+// optional options hash: cert/key enable HTTPS, client_ca requires client
+// certificates signed by that CA, redirect_http starts a plain HTTP
+// listener on another port that redirects to HTTPS, and max_body caps
+// request bodies in bytes.
+func listenWithOptions(port int, args []object.Object) (*httpListener, error) {
+	if len(args) == 0 {
+		return listenOn(port, nil, "")
+	}
+	opts, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, fmt.Errorf("server options must be HASH, got %s", args[0].Type())
+	}
+
+	l, err := listenTLS(port, opts)
+	if err != nil {
+		return nil, err
+	}
+	maxBody, _ := opts.Get("max_body")
+	if maxBody, ok := maxBody.(*object.Integer); ok {
+		l.maxBody.Store(maxBody.Value)
+	}
+	return l, nil
+}
+
+func listenTLS(port int, opts *object.Hash) (*httpListener, error) {
+	certOpt, _ := opts.Get("cert")
+	keyOpt, _ := opts.Get("key")
+	cert, hasCert := certOpt.(*object.String)
+	key, hasKey := keyOpt.(*object.String)
+	if !hasCert && !hasKey {
+		return listenOn(port, nil, "")
+	}
+	if !hasCert || !hasKey {
+		return nil, fmt.Errorf("TLS needs both `cert` and `key`")
+	}
+
+	pair, err := tls.LoadX509KeyPair(cert.Value, key.Value)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{pair}}
+	tlsFiles := fmt.Sprintf("cert=%q key=%q", cert.Value, key.Value)
+
+	ca, _ := opts.Get("client_ca")
+	if ca, ok := ca.(*object.String); ok {
+		pem, err := ioutil.ReadFile(ca.Value)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", ca.Value)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsFiles += fmt.Sprintf(" client_ca=%q", ca.Value)
+	}
+
+	l, err := listenOn(port, tlsConfig, tlsFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	redirect, _ := opts.Get("redirect_http")
+	if redirect, ok := redirect.(*object.Integer); ok {
+		plain, err := listenOn(int(redirect.Value), nil, "")
+		if err != nil {
+			return nil, err
+		}
+		err = plain.handle("/", func(w http.ResponseWriter, r *http.Request) {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(r.Host); err == nil {
+				host = h
+			}
+			target := fmt.Sprintf("https://%s:%d%s", host, l.port, r.URL.RequestURI())
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func stopAllListeners(ctx context.Context) {
+	httpListenersMu.Lock()
+	listeners := httpListeners
+	httpListeners = map[int]*httpListener{}
+	httpListenersMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(l *httpListener) {
+			defer wg.Done()
+			l.server.Shutdown(ctx)
+		}(l)
+	}
+	wg.Wait()
+}
+
+func (l *httpListener) handle(pattern string, handler http.HandlerFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	l.mux.HandleFunc(pattern, handler)
+	return nil
+}
+
+// routePattern turns "/users/:id" into a ServeMux pattern like
+// "GET /users/{id}" and returns the parameter names it found. Method
+// routes ending in a slash match that path exactly.
+func routePattern(method, route string) (string, []string) {
+	var params []string
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") && len(seg) > 1 {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+
+	pattern := strings.Join(segments, "/")
+	if method != "" {
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "{$}"
+		}
+		pattern = method + " " + pattern
+	}
+	return pattern, params
+}
+
+type httpApp struct {
+	mu        sync.Mutex
+	routes    []*httpRoute
+	listeners []*httpListener
+}
+
+type httpRoute struct {
+	method   string
+	path     string
+	router   *httpRouter
+	handlers []object.Object
+}
+
+type httpRouter struct {
+	env        *object.Environment
+	app        *httpApp
+	prefix     string
+	parent     *httpRouter
+	middleware []object.Object
+}
+
+func newRouter(env *object.Environment, app *httpApp, prefix string, parent *httpRouter) *httpRouter {
+	return &httpRouter{env: env, app: app, prefix: prefix, parent: parent}
+}
+
+func (rt *httpRouter) object() *object.Hash {
+	pairs := object.NewHash()
+	pairs.Set("use", &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("app.use needs at least one middleware FUNCTION")
+			}
+			rt.app.mu.Lock()
+			rt.middleware = append(rt.middleware, args...)
+			rt.app.mu.Unlock()
+			return NULL
+		},
+	})
+	pairs.Set("group", &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			prefix, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `app.group` must be STRING, got %s", args[0].Type())
+			}
+			group := newRouter(rt.env, rt.app, joinRoute(rt.prefix, prefix.Value), rt).object()
+			if len(args) == 2 {
+				if result := applyFunction(env, args[1], []object.Object{group}); isError(result) {
+					return result
+				}
+			}
+			return group
+		},
+	})
+	pairs.Set("listen", &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			port, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("argument to `app.listen` must be INTEGER, got %s", args[0].Type())
+			}
+			return rt.app.listen(int(port.Value), args[1:])
+		},
+	})
+
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		name := strings.ToLower(method)
+		pairs.Set(name, &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				if len(args) < 2 {
+					return newError("app.%s needs (path, handler)", name)
+				}
+				path, ok := args[0].(*object.String)
+				if !ok {
+					return newError("path for `app.%s` must be STRING, got %s", name, args[0].Type())
+				}
+				route := &httpRoute{
+					method:   method,
+					path:     joinRoute(rt.prefix, path.Value),
+					router:   rt,
+					handlers: args[1:],
+				}
+				return rt.app.add(route)
+			},
+		})
+	}
+
+	return pairs
+}
+
+func (rt *httpRouter) chain(route *httpRoute) []object.Object {
+	rt.app.mu.Lock()
+	defer rt.app.mu.Unlock()
+
+	var routers []*httpRouter
+	for r := rt; r != nil; r = r.parent {
+		routers = append([]*httpRouter{r}, routers...)
+	}
+
+	var handlers []object.Object
+	for _, r := range routers {
+		handlers = append(handlers, r.middleware...)
+	}
+	return append(handlers, route.handlers...)
+}
+
+func (a *httpApp) add(route *httpRoute) object.Object {
+	a.mu.Lock()
+	a.routes = append(a.routes, route)
+	listeners := append([]*httpListener{}, a.live()...)
+	a.mu.Unlock()
+
+	for _, l := range listeners {
+		if err := a.register(l, route); err != nil {
+			return newError("server error: %s", err.Error())
+		}
+	}
+	return NULL
+}
+
+func (a *httpApp) listen(port int, opts []object.Object) object.Object {
+	l, err := listenWithOptions(port, opts)
+	if err != nil {
+		return newError("server error: %s", err.Error())
+	}
+
+	a.mu.Lock()
+	a.listeners = append(a.live(), l)
+	routes := append([]*httpRoute{}, a.routes...)
+	a.mu.Unlock()
+
+	for _, route := range routes {
+		if err := a.register(l, route); err != nil {
+			return newError("server error: %s", err.Error())
+		}
+	}
+
+	fmt.Printf("B.A.S.E. App listening on :%d\n", l.port)
+	return &object.Integer{Value: int64(l.port)}
+}
+
+// live drops the listeners that server.stop or Shutdown have closed since
+// the app started on them. The caller must hold a.mu.
+func (a *httpApp) live() []*httpListener {
+	httpListenersMu.Lock()
+	defer httpListenersMu.Unlock()
+
+	listeners := a.listeners[:0]
+	for _, l := range a.listeners {
+		if httpListeners[l.port] == l {
+			listeners = append(listeners, l)
+		}
+	}
+	a.listeners = listeners
+	return listeners
+}
+
+func (a *httpApp) register(l *httpListener, route *httpRoute) error {
+	pattern, params := routePattern(route.method, route.path)
+	return l.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		l.serve(w, r, params, func(req, res object.Object) object.Object {
+			return runHandlers(route.router.env, route.router.chain(route), req, res)
+		})
+	})
+}
+
+// runHandlers calls each middleware as fn(req, res, next) and the final
+// handler as fn(req, res). Middleware that never calls next ends the chain.
+func runHandlers(env *object.Environment, handlers []object.Object, req, res object.Object) object.Object {
+	var call func(i int) object.Object
+	call = func(i int) object.Object {
+		if i >= len(handlers) {
+			return NULL
+		}
+		if i == len(handlers)-1 {
+			return applyFunction(env, handlers[i], []object.Object{req, res})
+		}
+		next := &object.Builtin{
+			Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
+				return call(i + 1)
+			},
+		}
+		return applyFunction(env, handlers[i], []object.Object{req, res, next})
+	}
+	return call(0)
+}
+
+func joinRoute(prefix, route string) string {
+	if prefix == "" {
+		return route
+	}
+	if route == "" || route == "/" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(route, "/")
+}
+
+// serve builds the req and res objects for a request and passes them to
+// run. Bodies over the listener's max_body get a 413 without running it.
+func (l *httpListener) serve(w http.ResponseWriter, r *http.Request, params []string, run func(req, res object.Object) object.Object) {
+	if maxBody := l.maxBody.Load(); maxBody > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	}
+
+	req, cleanup, err := newRequestObject(r, params)
+	defer cleanup()
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	res, sent := newResponseObject(w)
+	respondWithError(w, run(req, res), sent)
+}
+
+// newRequestObject builds the req hash passed to handlers. Uploaded files
+// are copied to temp files that the returned cleanup function removes.
+func newRequestObject(r *http.Request, params []string) (*object.Hash, func(), error) {
+	var tempFiles []string
+	cleanup := func() {
+		for _, name := range tempFiles {
+			os.Remove(name)
+		}
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	bodyObj, _ := decodeJSON(body)
+
+	form := object.NewHash()
+	files := object.NewHash()
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return nil, cleanup, err
+		}
+		setStringValues(form, r.PostForm)
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, cleanup, err
+		}
+		defer r.MultipartForm.RemoveAll()
+		setStringValues(form, r.MultipartForm.Value)
+
+		fields := make([]string, 0, len(r.MultipartForm.File))
+		for field := range r.MultipartForm.File {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			headers := r.MultipartForm.File[field]
+			uploads := make([]object.Object, 0, len(headers))
+			for _, header := range headers {
+				path, err := saveUpload(header)
+				if path != "" {
+					tempFiles = append(tempFiles, path)
+				}
+				if err != nil {
+					return nil, cleanup, err
+				}
+				upload := object.NewHash()
+				upload.Set("filename", &object.String{Value: header.Filename})
+				upload.Set("path", &object.String{Value: path})
+				upload.Set("size", &object.Integer{Value: header.Size})
+				upload.Set("content_type", &object.String{Value: header.Header.Get("Content-Type")})
+				uploads = append(uploads, upload)
+			}
+			if len(uploads) == 1 {
+				files.Set(field, uploads[0])
+			} else {
+				files.Set(field, &object.Array{Elements: uploads})
+			}
+		}
+	}
+
+	queryParams := object.NewHash()
+	setStringValues(queryParams, r.URL.Query())
+
+	pathParams := object.NewHash()
+	for _, name := range params {
+		pathParams.Set(name, &object.String{Value: r.PathValue(name)})
+	}
+
+	cookies := object.NewHash()
+	for _, cookie := range r.Cookies() {
+		cookies.Set(cookie.Name, &object.String{Value: cookie.Value})
+	}
+
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	req := object.NewHash()
+	req.Set("method", &object.String{Value: r.Method})
+	req.Set("path", &object.String{Value: r.URL.Path})
+	req.Set("query", queryParams)
+	req.Set("params", pathParams)
+	req.Set("headers", goTypeToBaseObject(parseHeaders(r.Header)))
+	req.Set("body", goTypeToBaseObject(bodyObj))
+	req.Set("raw_body", &object.String{Value: string(body)})
+	req.Set("form", form)
+	req.Set("files", files)
+	req.Set("cookies", cookies)
+	req.Set("ip", &object.String{Value: ip})
+	req.Set("host", &object.String{Value: r.Host})
+	return req, cleanup, nil
+}
+
+func setStringValues(hash *object.Hash, values map[string][]string) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := values[k]
+		if len(v) == 1 {
+			hash.Set(k, &object.String{Value: v[0]})
+		} else {
+			elements := make([]object.Object, len(v))
+			for i, val := range v {
+				elements[i] = &object.String{Value: val}
+			}
+			hash.Set(k, &object.Array{Elements: elements})
+		}
+	}
+}
+
+func saveUpload(header *multipart.FileHeader) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := ioutil.TempFile("", "base-upload-*"+filepath.Ext(header.Filename))
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return dst.Name(), err
+}
+
+// newResponseObject builds the res hash passed to handlers. The returned
+// flag reports whether a response has been written.
+func newResponseObject(w http.ResponseWriter) (*object.Hash, *bool) {
+	sent := new(bool)
+	customHeaders := map[string]string{}
+
+	resSend := &object.Builtin{
+		Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
+			if len(innerArgs) < 2 {
+				return newError("res.send needs (status, body)")
+			}
+			status, _ := innerArgs[0].(*object.Integer)
+			for k, v := range customHeaders {
+				w.Header().Set(k, v)
+			}
+			if w.Header().Get("Content-Type") == "" {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			w.WriteHeader(int(status.Value))
+			jsonBytes, _ := json.Marshal(baseObjectToGoType(innerArgs[1]))
+			w.Write(jsonBytes)
+			*sent = true
+			return NULL
+		},
+	}
+
+	resHtml := &object.Builtin{
+		Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
+			if len(innerArgs) < 2 {
+				return newError("res.html needs (status, content)")
+			}
+			status, _ := innerArgs[0].(*object.Integer)
+			for k, v := range customHeaders {
+				w.Header().Set(k, v)
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(int(status.Value))
+			w.Write([]byte(innerArgs[1].Inspect()))
+			*sent = true
+			return NULL
+		},
+	}
+
+	resHeader := &object.Builtin{
+		Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
+			if len(innerArgs) != 2 {
+				return newError("res.header needs (key, value)")
+			}
+			customHeaders[innerArgs[0].Inspect()] = innerArgs[1].Inspect()
+			return NULL
+		},
+	}
+
+	resFile := &object.Builtin{
+		Fn: func(innerEnv *object.Environment, innerArgs ...object.Object) object.Object {
+			if len(innerArgs) < 2 {
+				return newError("res.file needs (status, filepath)")
+			}
+			status, _ := innerArgs[0].(*object.Integer)
+			filePath, _ := innerArgs[1].(*object.String)
+			*sent = true
+			content, err := ioutil.ReadFile(filePath.Value)
+			if err != nil {
+				w.WriteHeader(404)
+				w.Write([]byte("File not found"))
+				return NULL
+			}
+			ext := filepath.Ext(filePath.Value)
+			mimeType := mime.TypeByExtension(ext)
+			if mimeType == "" {
+				mimeType = "application/octet-stream"
+			}
+			for k, v := range customHeaders {
+				w.Header().Set(k, v)
+			}
+			w.Header().Set("Content-Type", mimeType)
+			w.WriteHeader(int(status.Value))
+			w.Write(content)
+			return NULL
+		},
+	}
+
+	res := object.NewHash()
+	res.Set("send", resSend)
+	res.Set("html", resHtml)
+	res.Set("header", resHeader)
+	res.Set("file", resFile)
+	return res, sent
+}
+
+// respondWithError turns an uncaught handler error into a 500 response
+// unless the handler already replied.
+func respondWithError(w http.ResponseWriter, result object.Object, sent *bool) {
+	errObj, ok := result.(*object.Error)
+	if !ok || *sent {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	jsonBytes, _ := json.Marshal(map[string]string{"error": errObj.Message})
+	w.Write(jsonBytes)
+}
+
 func default404Page() string {
 	return `<!DOCTYPE html>
 <html>
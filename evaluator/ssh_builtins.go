@@ -51,21 +51,17 @@ func RegisterSSHBuiltins() {
 
 			output, err := session.CombinedOutput(command)
 			if err != nil {
-				return &object.Hash{
-					Pairs: map[string]object.Object{
-						"exit_code": &object.Integer{Value: 1},
-						"output":    &object.String{Value: string(output)},
-						"error":     &object.String{Value: err.Error()},
-					},
-				}
+				result := object.NewHash()
+				result.Set("exit_code", &object.Integer{Value: 1})
+				result.Set("output", &object.String{Value: string(output)})
+				result.Set("error", &object.String{Value: err.Error()})
+				return result
 			}
 
-			return &object.Hash{
-				Pairs: map[string]object.Object{
-					"exit_code": &object.Integer{Value: 0},
-					"output":    &object.String{Value: string(output)},
-				},
-			}
+			result := object.NewHash()
+			result.Set("exit_code", &object.Integer{Value: 0})
+			result.Set("output", &object.String{Value: string(output)})
+			return result
 		},
 	}
 }
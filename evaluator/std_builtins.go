@@ -3,7 +3,9 @@ package evaluator
 import (
 	"base/object"
 	"math"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 func RegisterStdBuiltins() {
@@ -145,6 +147,124 @@ func RegisterStdBuiltins() {
 		},
 	}
 
+	builtins["math.overflow"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			mode, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `math.overflow` must be STRING, got %s", args[0].Type())
+			}
+			switch mode.Value {
+			case "wrap":
+				checkOverflow.Store(false)
+			case "error":
+				checkOverflow.Store(true)
+			default:
+				return newError("unknown overflow mode %q, want \"wrap\" or \"error\"", mode.Value)
+			}
+			return NULL
+		},
+	}
+
+	builtins["math.floor_div"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return floorDivMod("math.floor_div", args, func(q, r int64) int64 { return q }, func(a, b float64) float64 {
+				return math.Floor(a / b)
+			})
+		},
+	}
+
+	builtins["math.mod"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return floorDivMod("math.mod", args, func(q, r int64) int64 { return r }, func(a, b float64) float64 {
+				return a - b*math.Floor(a/b)
+			})
+		},
+	}
+
+	builtins["number.format"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			decimals := int64(-1)
+			sep := ""
+			if len(args) == 2 {
+				opts, ok := args[1].(*object.Hash)
+				if !ok {
+					return newError("second argument to `number.format` must be HASH, got %s", args[1].Type())
+				}
+				if d, ok := opts.Get("decimals"); ok {
+					n, ok := d.(*object.Integer)
+					if !ok || n.Value < 0 {
+						return newError("`decimals` must be a non-negative INTEGER, got %s", d.Inspect())
+					}
+					decimals = n.Value
+				}
+				thousands, _ := opts.Get("thousands")
+				switch t := thousands.(type) {
+				case nil:
+				case *object.String:
+					sep = t.Value
+				case *object.Boolean:
+					if t.Value {
+						sep = ","
+					}
+				default:
+					return newError("`thousands` must be STRING or BOOLEAN, got %s", t.Type())
+				}
+			}
+
+			var s string
+			switch n := args[0].(type) {
+			case *object.Integer:
+				s = strconv.FormatInt(n.Value, 10)
+				if decimals > 0 {
+					s += "." + strings.Repeat("0", int(decimals))
+				}
+			case *object.Float:
+				s = strconv.FormatFloat(n.Value, 'f', int(decimals), 64)
+			default:
+				return newError("first argument to `number.format` must be INTEGER or FLOAT, got %s", args[0].Type())
+			}
+			if sep != "" {
+				s = groupThousands(s, sep)
+			}
+			return &object.String{Value: s}
+		},
+	}
+
+	builtins["range"] = &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+			}
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				n, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("arguments to `range` must be INTEGER, got %s", arg.Type())
+				}
+				bounds[i] = n.Value
+			}
+			r := &object.Range{Step: 1}
+			switch len(bounds) {
+			case 1:
+				r.Stop = bounds[0]
+			case 2:
+				r.Start, r.Stop = bounds[0], bounds[1]
+			case 3:
+				r.Start, r.Stop, r.Step = bounds[0], bounds[1], bounds[2]
+			}
+			if r.Step == 0 {
+				return newError("`range` step must not be zero")
+			}
+			return r
+		},
+	}
+
 	builtins["type"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -278,3 +398,69 @@ func RegisterStdBuiltins() {
 		},
 	}
 }
+
+// checkOverflow makes integer arithmetic fail with an error instead of
+// wrapping around; see math.overflow. It is a process-global setting rather
+// than part of an environment: once a script turns it on, it stays on for
+// every handler, job and spawned task until the script turns it off.
+var checkOverflow atomic.Bool
+
+// floorDivMod implements math.floor_div and math.mod, which round the
+// quotient towards negative infinity so the remainder takes the sign of
+// the divisor: math.floor_div(-7, 2) is -4 and math.mod(-7, 2) is 1.
+func floorDivMod(name string, args []object.Object, pickInt func(q, r int64) int64, floatOp func(a, b float64) float64) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	a, okA := args[0].(*object.Integer)
+	b, okB := args[1].(*object.Integer)
+	if okA && okB {
+		if b.Value == 0 {
+			return newError("division by zero in `%s`", name)
+		}
+		if name == "math.floor_div" && checkOverflow.Load() && integerOverflowed("/", a.Value, b.Value, a.Value/b.Value) {
+			return newError("integer overflow in `%s`", name)
+		}
+		q, r := a.Value/b.Value, a.Value%b.Value
+		if r != 0 && (r < 0) != (b.Value < 0) {
+			q--
+			r += b.Value
+		}
+		return &object.Integer{Value: pickInt(q, r)}
+	}
+
+	x, okX := numberValue(args[0])
+	y, okY := numberValue(args[1])
+	if !okX || !okY {
+		return newError("arguments to `%s` must be INTEGER or FLOAT", name)
+	}
+	if y == 0 {
+		return newError("division by zero in `%s`", name)
+	}
+	return &object.Float{Value: floatOp(x, y)}
+}
+
+// groupThousands inserts sep between each group of three digits in the
+// integer part of a formatted number, leaving the sign and fraction alone.
+func groupThousands(s, sep string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	if len(intPart) <= 3 || strings.Trim(intPart, "0123456789") != "" {
+		return sign + s
+	}
+
+	var out strings.Builder
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			out.WriteString(sep)
+		}
+		out.WriteRune(ch)
+	}
+	return sign + out.String() + frac
+}
@@ -6,40 +6,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-
-	"github.com/robfig/cron/v3"
 )
 
-var globalCron = cron.New()
-
 func RegisterSystemBuiltins() {
-	globalCron.Start()
-
-	builtins["schedule"] = &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
-			}
-			spec, ok1 := args[0].(*object.String)
-			fn, ok2 := args[1].(*object.Function)
-
-			if !ok1 || !ok2 {
-				return newError("arguments to `schedule` must be (STRING, FUNCTION)")
-			}
-
-			_, err := globalCron.AddFunc(spec.Value, func() {
-				KeepAlive = true
-				applyFunction(env, fn, []object.Object{})
-			})
-
-			if err != nil {
-				return newError("cron schedule error: %s", err.Error())
-			}
-
-			return TRUE
-		},
-	}
-
 	builtins["archive.zip"] = &object.Builtin{
 		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) != 2 {
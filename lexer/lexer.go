@@ -1,21 +1,43 @@
 package lexer
 
-import "base/token"
+import (
+	"base/token"
+	"strconv"
+	"strings"
+)
 
 type Lexer struct {
 	input        string
+	file         string
 	position     int
 	readPosition int
 	ch           byte
+	line         int
+	column       int
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFile(input, "")
+}
+
+func NewWithFile(input, file string) *Lexer {
+	return NewAt(input, token.Position{File: file, Line: 1, Column: 1})
+}
+
+// NewAt lexes input as if it started at pos, so that code embedded in a
+// larger source (like a string interpolation) reports real positions.
+func NewAt(input string, pos token.Position) *Lexer {
+	l := &Lexer{input: input, file: pos.File, line: pos.Line, column: pos.Column - 1}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -37,6 +59,7 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.eatWhitespaceAndComments()
+	pos := token.Position{File: l.file, Line: l.line, Column: l.column}
 
 	switch l.ch {
 	case '=':
@@ -44,13 +67,26 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "=>"}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.PLUS_ASSIGN, Literal: "+="}
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.MINUS_ASSIGN, Literal: "-="}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -60,9 +96,19 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.SLASH_ASSIGN, Literal: "/="}
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.ASTERISK_ASSIGN, Literal: "*="}
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '%':
 		tok = newToken(token.MOD, l.ch)
 	case '<':
@@ -92,13 +138,36 @@ func (l *Lexer) NextToken() token.Token {
 	case '&':
 		tok = newToken(token.BIT_AND, l.ch)
 	case '|':
-		tok = newToken(token.BIT_OR, l.ch)
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.PIPE, Literal: "|>"}
+		} else {
+			tok = newToken(token.BIT_OR, l.ch)
+		}
 	case '^':
 		tok = newToken(token.BIT_XOR, l.ch)
 	case '~':
 		tok = newToken(token.BIT_NOT, l.ch)
 	case '?':
-		tok = newToken(token.QUESTION, l.ch)
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL_DOT, Literal: "?."}
+		case '[':
+			// `?[` is only an optional index when it hugs its operand, so
+			// `ok ?[1] : [2]` still lexes as a ternary over an array.
+			if l.followsWhitespace() {
+				tok = newToken(token.QUESTION, l.ch)
+				break
+			}
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL_LBRACKET, Literal: "?["}
+		default:
+			tok = newToken(token.QUESTION, l.ch)
+		}
 	case ':':
 		tok = newToken(token.COLON, l.ch)
 	case ';':
@@ -106,7 +175,16 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '.':
-		tok = newToken(token.DOT, l.ch)
+		if l.peekChar() == '.' && l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else if l.peekChar() == '.' {
+			l.readChar()
+			tok = token.Token{Type: token.DOTDOT, Literal: ".."}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -120,8 +198,17 @@ func (l *Lexer) NextToken() token.Token {
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case '"', '\'':
+		raw, interpolated := l.readString(l.ch)
+		if interpolated {
+			tok.Type = token.TEMPLATE
+			tok.Literal = raw
+		} else {
+			tok.Type = token.STRING
+			tok.Literal = Unescape(raw)
+		}
+	case '`':
 		tok.Type = token.STRING
-		tok.Literal = l.readString(l.ch)
+		tok.Literal = l.readRawString()
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -129,9 +216,11 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Type, tok.Literal = l.readNumber()
+			tok.Pos = pos
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -139,6 +228,7 @@ func (l *Lexer) NextToken() token.Token {
 	}
 
 	l.readChar()
+	tok.Pos = pos
 	return tok
 }
 
@@ -175,6 +265,19 @@ func (l *Lexer) eatWhitespaceAndComments() {
 	}
 }
 
+// followsWhitespace reports whether the current character starts the input
+// or comes straight after whitespace.
+func (l *Lexer) followsWhitespace() bool {
+	if l.position == 0 {
+		return true
+	}
+	switch l.input[l.position-1] {
+	case ' ', '\t', '\n', '\r':
+		return true
+	}
+	return false
+}
+
 func (l *Lexer) readIdentifier() string {
 	position := l.position
 	for isLetter(l.ch) || isDigit(l.ch) {
@@ -183,35 +286,156 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readString(quote byte) string {
+// readString returns the raw contents of a quoted string and whether it
+// contains ${...} interpolations, which only double-quoted strings support.
+func (l *Lexer) readString(quote byte) (string, bool) {
 	position := l.position + 1
+	interpolated := false
 	for {
 		l.readChar()
-		if l.ch == '\\' && l.peekChar() == quote {
+		if l.ch == '\\' && l.peekChar() != 0 {
 			l.readChar()
 			continue
 		}
+		if quote == '"' && l.ch == '$' && l.peekChar() == '{' {
+			interpolated = true
+			end := InterpolationEnd(l.input, l.position+2)
+			for l.position < end && l.ch != 0 {
+				l.readChar()
+			}
+			if l.ch == 0 {
+				// Never closed; the parser reports the unterminated ${.
+				break
+			}
+			continue
+		}
 		if l.ch == quote || l.ch == 0 {
 			break
 		}
 	}
+	return l.input[position:l.position], interpolated
+}
+
+func (l *Lexer) readRawString() string {
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == '`' || l.ch == 0 {
+			break
+		}
+	}
 	return l.input[position:l.position]
 }
 
+// InterpolationEnd returns the index of the '}' closing an interpolation
+// whose expression starts at start, skipping nested braces and strings.
+// It returns len(s) if the interpolation is never closed.
+func InterpolationEnd(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case '"', '\'', '`':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' && quote != '`' {
+					i++
+				}
+			}
+		}
+	}
+	return len(s)
+}
+
+// Unescape decodes the escape sequences allowed in quoted strings: \n, \t,
+// \r, \\, escaped quotes, \$ and \u{hex}. Unknown escapes are kept as-is.
+func Unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case '\\', '"', '\'', '$', '`':
+			out.WriteByte(s[i])
+		case 'u':
+			if end := strings.IndexByte(s[i:], '}'); i+1 < len(s) && s[i+1] == '{' && end > 0 {
+				if code, err := strconv.ParseUint(s[i+2:i+end], 16, 32); err == nil {
+					out.WriteRune(rune(code))
+					i += end
+					continue
+				}
+			}
+			out.WriteString("\\u")
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(s[i])
+		}
+	}
+	return out.String()
+}
+
+// readNumber reads an integer or float literal. Integers may carry a 0x,
+// 0o or 0b prefix, floats may have an exponent, and digits may be grouped
+// with underscores. The parser rejects malformed digits, so a literal such
+// as 1.2.3 is kept as one token to get a single clear error.
 func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
-	isFloat := false
-	for isDigit(l.ch) || l.ch == '.' {
-		if l.ch == '.' {
-			isFloat = true
+	if l.ch == '0' && strings.ContainsRune("xXoObB", rune(l.peekChar())) {
+		l.readChar()
+		l.readChar()
+		for isLetter(l.ch) || isDigit(l.ch) {
+			l.readChar()
 		}
+		return token.INT, l.input[position:l.position]
+	}
+
+	tokType := token.TokenType(token.INT)
+	l.readDigits()
+	for l.ch == '.' && isDigit(l.peekChar()) {
+		tokType = token.FLOAT
 		l.readChar()
+		l.readDigits()
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if (next == '+' || next == '-') && l.readPosition+1 < len(l.input) {
+			next = l.input[l.readPosition+1]
+		}
+		if isDigit(next) {
+			tokType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			l.readDigits()
+		}
 	}
+	return tokType, l.input[position:l.position]
+}
 
-	if isFloat {
-		return token.FLOAT, l.input[position:l.position]
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
 	}
-	return token.INT, l.input[position:l.position]
 }
 
 func isLetter(ch byte) bool {
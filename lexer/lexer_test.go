@@ -15,7 +15,7 @@ let add = function(x, y) {
 };
 
 let result = add(five, ten);
-!-/*5;
+!-/ *5;
 5 < 10 > 5;
 
 if 5 < 10 {
@@ -173,3 +173,164 @@ if 5 < 10 {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x + "hi"`
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.IDENT, 2, 3},
+		{token.PLUS, 2, 5},
+		{token.STRING, 2, 7},
+		{token.EOF, 2, 11},
+	}
+
+	l := NewWithFile(input, "main.base")
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Pos.Line, tok.Pos.Column)
+		}
+		if tok.Pos.File != "main.base" {
+			t.Fatalf("tests[%d] - file wrong. got=%q", i, tok.Pos.File)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	input := "\"a\\tb\\n\" 'it\\'s' \"\\u{1F600}\\\\\" `raw\\n\nline` \"hi ${name}\" \"\\${name}\" \"${h[\"}\"]}!\""
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING, "a\tb\n"},
+		{token.STRING, "it's"},
+		{token.STRING, "\U0001F600\\"},
+		{token.STRING, "raw\\n\nline"},
+		{token.TEMPLATE, "hi ${name}"},
+		{token.STRING, "${name}"},
+		{token.TEMPLATE, "${h[\"}\"]}!"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestUnterminatedInterpolation(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+	}{
+		{`"${`, "${"},
+		{`"a ${b`, "a ${b"},
+		{`"x ${1"`, "x ${1\""},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		tok := l.NextToken()
+		if tok.Type != token.TEMPLATE || tok.Literal != tt.expectedLiteral {
+			t.Errorf("wrong token for %q. got=%s %q, want=TEMPLATE %q", tt.input, tok.Type, tok.Literal, tt.expectedLiteral)
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("expected EOF after %q. got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestRangeAndArrowTokens(t *testing.T) {
+	input := "400..499 => 1.5 ...rest |> |"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "400"},
+		{token.DOTDOT, ".."},
+		{token.INT, "499"},
+		{token.ARROW, "=>"},
+		{token.FLOAT, "1.5"},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.PIPE, "|>"},
+		{token.BIT_OR, "|"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	input := "0xFF 0b1010 0o17 1_000_000 1e-6 2.5E+3 1.2.3 4e x.e"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xFF"},
+		{token.INT, "0b1010"},
+		{token.INT, "0o17"},
+		{token.INT, "1_000_000"},
+		{token.FLOAT, "1e-6"},
+		{token.FLOAT, "2.5E+3"},
+		{token.FLOAT, "1.2.3"},
+		{token.INT, "4"},
+		{token.IDENT, "e"},
+		{token.IDENT, "x"},
+		{token.DOT, "."},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -99,14 +99,18 @@ func registerAllBuiltins() {
 	evaluator.RegisterJSONBuiltins()
 	evaluator.RegisterStdBuiltins()
 	evaluator.RegisterListBuiltins()
+	evaluator.RegisterHashBuiltins()
 	evaluator.RegisterDBBuiltins()
 	evaluator.RegisterCryptoBuiltins()
 	evaluator.RegisterDataBuiltins()
 	evaluator.RegisterSSHBuiltins()
 	evaluator.RegisterServerBuiltins()
 	evaluator.RegisterSystemBuiltins()
+	evaluator.RegisterScheduleBuiltins()
 	evaluator.RegisterNotifyBuiltins()
 	evaluator.RegisterChannelBuiltins()
+	evaluator.RegisterAsyncBuiltins()
+	evaluator.RegisterLifecycleBuiltins()
 	evaluator.RegisterWSBuiltins()
 }
 
@@ -127,26 +131,30 @@ func printHelp() {
 
 	fmt.Printf("%sCORE MODULES:%s\n", Yellow, Reset)
 	fmt.Printf("  %shttp%s     get, post, put, patch, delete, ping\n", Cyan, Reset)
-	fmt.Printf("  %sdb%s       connect, query, insert, update, delete, exec, aggregate\n", Cyan, Reset)
-	fmt.Printf("  %sserver%s   listen, static\n", Cyan, Reset)
-	fmt.Printf("  %sfile%s     read, write, append, exists, delete, list, mkdir, replace\n", Cyan, Reset)
+	fmt.Printf("  %sdb%s       connect, query, stream, insert, update, delete, exec, aggregate\n", Cyan, Reset)
+	fmt.Printf("  %sserver%s   listen, static, app, stop\n", Cyan, Reset)
+	fmt.Printf("  %sfile%s     read, lines, write, append, exists, delete, list, mkdir, replace\n", Cyan, Reset)
 	fmt.Printf("  %scrypto%s   uuid, hash, encrypt_file, decrypt_file\n", Cyan, Reset)
-	fmt.Printf("  %ssys%s      exec, timestamp, version\n", Cyan, Reset)
-	fmt.Printf("  %smath%s     abs, sqrt, pow, round, sin, cos, log\n", Cyan, Reset)
+	fmt.Printf("  %ssys%s      exec, timestamp, version, strict\n", Cyan, Reset)
+	fmt.Printf("  %smath%s     abs, sqrt, pow, round, sin, cos, log, floor_div, mod, overflow\n", Cyan, Reset)
+	fmt.Printf("  %snumber%s   format\n", Cyan, Reset)
 	fmt.Printf("  %sstring%s   upper, lower, replace, slice, pad_left\n", Cyan, Reset)
-	fmt.Printf("  %slist%s     map, filter, sort, contains, length\n", Cyan, Reset)
+	fmt.Printf("  %slist%s     map, filter, sort, contains, length, take, collect\n", Cyan, Reset)
+	fmt.Printf("  %shash%s     keys, values, has, delete, merge, length\n", Cyan, Reset)
 	fmt.Printf("  %sencode%s   base64\n", Cyan, Reset)
 	fmt.Printf("  %sdecode%s   base64\n", Cyan, Reset)
 	fmt.Printf("  %scsv%s       read\n", Cyan, Reset)
 	fmt.Printf("  %syaml%s      write\n", Cyan, Reset)
 	fmt.Printf("  %sssh%s       exec remote commands\n", Cyan, Reset)
 	fmt.Printf("  %snotify%s    discord, email\n", Cyan, Reset)
-	fmt.Printf("  %sschedule%s  recurring jobs\n", Cyan, Reset)
+	fmt.Printf("  %sschedule%s  list, next, pause, resume, remove\n", Cyan, Reset)
 	fmt.Printf("  %schan%s      thread-safe channels\n\n", Cyan, Reset)
 
 	fmt.Printf("%sUTILITIES:%s\n", Yellow, Reset)
 	fmt.Printf("  log(msg, lvl?)   Wait(sec)      Type(v)  \n")
-	fmt.Printf("  print(args..)    wait_all()     env.get(n)\n\n")
+	fmt.Printf("  print(args..)    wait_all()     env.get(n)\n")
+	fmt.Printf("  await_all(fs)    await_any(fs)  on_shutdown(fn)\n")
+	fmt.Printf("  shutdown_timeout(sec)   range(start?, stop, step?)\n\n")
 
 	fmt.Printf("%sEXAMPLES:%s\n", Yellow, Reset)
 	fmt.Printf("  base script.base              Run a script\n")
@@ -162,7 +170,7 @@ func checkFile(filename string) {
 		os.Exit(1)
 	}
 
-	l := lexer.New(string(content))
+	l := lexer.NewWithFile(string(content), filename)
 	p := parser.New(l)
 	p.ParseProgram()
 
@@ -295,8 +303,9 @@ func evalString(input string) {
 	env := object.NewEnvironment()
 	evaluated := evaluator.Eval(program, env)
 
-	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
-		fmt.Println(evaluated.Inspect())
+	if errObj, ok := evaluated.(*object.Error); ok {
+		fmt.Println(errObj.Inspect())
+		fmt.Print(errObj.StackTrace())
 		os.Exit(1)
 	}
 
@@ -305,6 +314,7 @@ func evalString(input string) {
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		<-quit
 	}
+	evaluator.Shutdown(env)
 }
 
 func runFile(filename string) {
@@ -314,7 +324,7 @@ func runFile(filename string) {
 		os.Exit(1)
 	}
 
-	l := lexer.New(string(content))
+	l := lexer.NewWithFile(string(content), filename)
 	p := parser.New(l)
 	program := p.ParseProgram()
 
@@ -331,8 +341,9 @@ func runFile(filename string) {
 	env := object.NewEnvironment()
 	evaluated := evaluator.Eval(program, env)
 
-	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
-		fmt.Println(evaluated.Inspect())
+	if errObj, ok := evaluated.(*object.Error); ok {
+		fmt.Println(errObj.Inspect())
+		fmt.Print(errObj.StackTrace())
 		os.Exit(1)
 	}
 
@@ -341,6 +352,7 @@ func runFile(filename string) {
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		<-quit
 	}
+	evaluator.Shutdown(env)
 }
 
 func registerImportHandler() {
@@ -350,12 +362,12 @@ func registerImportHandler() {
 			return nil, err
 		}
 
-		l := lexer.New(string(content))
+		l := lexer.NewWithFile(string(content), path)
 		p := parser.New(l)
 		program := p.ParseProgram()
 
 		if len(p.Errors()) != 0 {
-			return nil, fmt.Errorf("parse errors in %s", path)
+			return nil, fmt.Errorf("parse errors in %s: %s", path, strings.Join(p.Errors(), "; "))
 		}
 
 		env := object.NewEnvironment()
@@ -2,10 +2,15 @@ package object
 
 import (
 	"base/ast"
+	"base/token"
 	"bytes"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type ObjectType string
@@ -24,6 +29,13 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
+	FUTURE_OBJ       = "FUTURE"
+	CHANNEL_OBJ      = "CHANNEL"
+	RANGE_OBJ        = "RANGE"
+	STREAM_OBJ       = "STREAM"
+	GENERATOR_OBJ    = "GENERATOR"
 )
 
 type Object interface {
@@ -43,7 +55,25 @@ type Float struct {
 }
 
 func (f *Float) Type() ObjectType { return FLOAT_OBJ }
-func (f *Float) Inspect() string  { return fmt.Sprintf("%f", f.Value) }
+func (f *Float) Inspect() string  { return FormatFloat(f.Value) }
+
+// FormatFloat prints the shortest form that parses back to the same value,
+// switching to exponent notation for very large or very small magnitudes.
+// Infinities and NaN print as +Inf, -Inf and NaN.
+func FormatFloat(v float64) string {
+	abs := math.Abs(v)
+	if math.IsInf(v, 0) || math.IsNaN(v) || abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	// Go pads the exponent to two digits; print 1e-7 rather than 1e-07.
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	mantissa, exp, found := strings.Cut(s, "e")
+	if !found || len(exp) < 2 {
+		return s
+	}
+	return mantissa + "e" + exp[:1] + strings.TrimLeft(exp[1:], "0")
+}
 
 type Boolean struct {
 	Value bool
@@ -71,18 +101,75 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
+type StackFrame struct {
+	Function string
+	Position token.Position
+}
+
 type Error struct {
-	Message string
+	Message  string
+	Position token.Position
+	Stack    []StackFrame
+	frameAt  token.Position
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string {
+	if e.Position.IsValid() {
+		return "ERROR: " + e.Position.String() + ": " + e.Message
+	}
+	return "ERROR: " + e.Message
+}
+
+// Locate records pos as the origin of the error and as the location inside
+// the frame currently being unwound, unless either is already known.
+func (e *Error) Locate(pos token.Position) {
+	if !e.Position.IsValid() {
+		e.Position = pos
+	}
+	if !e.frameAt.IsValid() {
+		e.frameAt = pos
+	}
+}
+
+func (e *Error) PushFrame(function string) {
+	if function == "" {
+		function = "<anonymous>"
+	}
+	e.Stack = append(e.Stack, StackFrame{Function: function, Position: e.frameAt})
+	e.frameAt = token.Position{}
+}
+
+func (e *Error) StackTrace() string {
+	var out bytes.Buffer
+
+	for _, frame := range e.Stack {
+		out.WriteString("    at " + frame.Function)
+		if frame.Position.IsValid() {
+			out.WriteString(" (" + frame.Position.String() + ")")
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
 
 type Environment struct {
 	store map[string]Object
 	outer *Environment
 	wg    *sync.WaitGroup
 	mu    sync.RWMutex
+	gen   *Generator
 }
 
 func NewEnvironment() *Environment {
@@ -96,6 +183,19 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 	return env
 }
 
+// SetGenerator marks e as the body environment of a running generator.
+func (e *Environment) SetGenerator(g *Generator) { e.gen = g }
+
+// Generator returns the generator whose body e belongs to, if any.
+func (e *Environment) Generator() *Generator {
+	for env := e; env != nil; env = env.outer {
+		if env.gen != nil {
+			return env.gen
+		}
+	}
+	return nil
+}
+
 func (e *Environment) Root() *Environment {
 	if e.outer == nil {
 		return e
@@ -142,11 +242,17 @@ func (e *Environment) Set(name string, val Object) Object {
 func (e *Environment) Export() *Hash {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	pairs := make(map[string]Object)
-	for k, v := range e.store {
-		pairs[k] = v
+	names := make([]string, 0, len(e.store))
+	for k := range e.store {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	hash := NewHash()
+	for _, k := range names {
+		hash.Set(k, e.store[k])
 	}
-	return &Hash{Pairs: pairs}
+	return hash
 }
 
 func (e *Environment) Update(name string, val Object) Object {
@@ -169,7 +275,10 @@ func (e *Environment) Update(name string, val Object) Object {
 }
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Name       string
+	Async      bool
+	Generator  bool
+	Parameters []*ast.Parameter
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -183,7 +292,13 @@ func (f *Function) Inspect() string {
 		params = append(params, p.String())
 	}
 
+	if f.Async {
+		out.WriteString("async ")
+	}
 	out.WriteString("function")
+	if f.Generator {
+		out.WriteString("*")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") {\n")
@@ -222,17 +337,61 @@ func (a *Array) Inspect() string {
 	return out.String()
 }
 
+// Hash keeps its keys in insertion order. Pairs are only reachable through
+// NewHash, Get, Set and Delete so the order cannot drift from the map.
 type Hash struct {
-	Pairs map[string]Object
+	pairs map[string]Object
+	keys  []string
+}
+
+func NewHash() *Hash {
+	return &Hash{pairs: make(map[string]Object)}
+}
+
+func (h *Hash) Get(key string) (Object, bool) {
+	val, ok := h.pairs[key]
+	return val, ok
+}
+
+// Set stores val under key. Overwriting a key keeps its original position.
+func (h *Hash) Set(key string, val Object) {
+	if h.pairs == nil {
+		h.pairs = make(map[string]Object)
+	}
+	if _, exists := h.pairs[key]; !exists {
+		h.keys = append(h.keys, key)
+	}
+	h.pairs[key] = val
+}
+
+func (h *Hash) Delete(key string) (Object, bool) {
+	val, ok := h.pairs[key]
+	if !ok {
+		return nil, false
+	}
+	delete(h.pairs, key)
+	keys := make([]string, 0, len(h.pairs))
+	for _, k := range h.keys {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	h.keys = keys
+	return val, true
 }
 
+// Keys returns the keys in insertion order.
+func (h *Hash) Keys() []string { return h.keys }
+
+func (h *Hash) Len() int { return len(h.pairs) }
+
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
 func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for k, v := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%q: %s", k, v.Inspect()))
+	for _, k := range h.Keys() {
+		pairs = append(pairs, fmt.Sprintf("%q: %s", k, h.pairs[k].Inspect()))
 	}
 
 	out.WriteString("{")
@@ -241,3 +400,319 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+type Future struct {
+	done  chan struct{}
+	value Object
+}
+
+func NewFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) Type() ObjectType { return FUTURE_OBJ }
+func (f *Future) Inspect() string {
+	select {
+	case <-f.done:
+		return "future(resolved)"
+	default:
+		return "future(pending)"
+	}
+}
+
+func (f *Future) Resolve(value Object) {
+	f.value = value
+	close(f.done)
+}
+
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *Future) Wait() Object {
+	<-f.done
+	return f.value
+}
+
+type Channel struct {
+	mu       sync.Mutex
+	items    []Object
+	capacity int
+	closed   bool
+	changed  chan struct{}
+}
+
+func NewChannel(capacity int) *Channel {
+	return &Channel{capacity: capacity, changed: make(chan struct{})}
+}
+
+func (c *Channel) Type() ObjectType { return CHANNEL_OBJ }
+func (c *Channel) Inspect() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity > 0 {
+		return fmt.Sprintf("chan(%d/%d)", len(c.items), c.capacity)
+	}
+	return fmt.Sprintf("chan(%d)", len(c.items))
+}
+
+// Changed returns a channel that is closed the next time a value is sent,
+// received or the channel is closed.
+func (c *Channel) Changed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.changed
+}
+
+func (c *Channel) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Send blocks while a bounded channel is full. It reports false if the
+// channel is closed.
+func (c *Channel) Send(obj Object) bool {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return false
+		}
+		if c.capacity <= 0 || len(c.items) < c.capacity {
+			c.items = append(c.items, obj)
+			c.notify()
+			c.mu.Unlock()
+			return true
+		}
+		changed := c.changed
+		c.mu.Unlock()
+		<-changed
+	}
+}
+
+// TryRecv takes the next value without blocking. ok is false when nothing
+// is buffered; closed reports whether the channel is closed and drained.
+func (c *Channel) TryRecv() (obj Object, ok bool, closed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.items) > 0 {
+		obj = c.items[0]
+		c.items = c.items[1:]
+		c.notify()
+		return obj, true, false
+	}
+	return nil, false, c.closed
+}
+
+// Recv blocks until a value arrives, the channel is closed or the timeout
+// elapses. A negative timeout waits forever.
+func (c *Channel) Recv(timeout time.Duration) (Object, bool) {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		changed := c.Changed()
+		obj, ok, closed := c.TryRecv()
+		if ok {
+			return obj, true
+		}
+		if closed {
+			return nil, false
+		}
+		select {
+		case <-changed:
+		case <-expired:
+			return nil, false
+		}
+	}
+}
+
+func (c *Channel) Close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	c.notify()
+	return true
+}
+
+func (c *Channel) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+func (c *Channel) Items() []Object {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	items := make([]Object, len(c.items))
+	copy(items, c.items)
+	return items
+}
+
+// Iterator produces the values of a foreach loop one at a time. Next
+// reports false once the source is exhausted, and an *Error value ends the
+// loop with that error. Iterators over files, cursors and other resources
+// should also implement io.Closer so that leaving a loop early releases
+// them.
+type Iterator interface {
+	Next() (Object, bool)
+}
+
+// Iterable is implemented by objects that foreach can walk lazily.
+type Iterable interface {
+	Iterate() Iterator
+}
+
+// IteratorFunc adapts a plain function to the Iterator interface.
+type IteratorFunc func() (Object, bool)
+
+func (f IteratorFunc) Next() (Object, bool) { return f() }
+
+func (c *Channel) Iterate() Iterator {
+	return IteratorFunc(func() (Object, bool) { return c.Recv(-1) })
+}
+
+// Range is the integer sequence from Start towards Stop in steps of Step.
+// Stop itself is only included for Inclusive ranges, which is what the
+// a..b operator builds.
+type Range struct {
+	Start     int64
+	Stop      int64
+	Step      int64
+	Inclusive bool
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	if r.Inclusive && r.Step == 1 {
+		return fmt.Sprintf("%d..%d", r.Start, r.Stop)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.Stop, r.Step)
+}
+
+func (r *Range) contains(n int64) bool {
+	switch {
+	case r.Step > 0 && r.Inclusive:
+		return n <= r.Stop
+	case r.Step > 0:
+		return n < r.Stop
+	case r.Inclusive:
+		return n >= r.Stop
+	default:
+		return n > r.Stop
+	}
+}
+
+func (r *Range) Iterate() Iterator {
+	next, done := r.Start, false
+	return IteratorFunc(func() (Object, bool) {
+		if done || !r.contains(next) {
+			return nil, false
+		}
+		val := next
+		next += r.Step
+		// Stepping past either end of int64 wraps around; stop instead.
+		done = (r.Step > 0) != (next > val)
+		return &Integer{Value: val}, true
+	})
+}
+
+// Stream is a single-pass sequence that builtins such as file.lines and
+// db.stream return instead of loading everything into an Array.
+type Stream struct {
+	Name string
+	Iter Iterator
+}
+
+func (s *Stream) Type() ObjectType  { return STREAM_OBJ }
+func (s *Stream) Inspect() string   { return fmt.Sprintf("stream(%s)", s.Name) }
+func (s *Stream) Iterate() Iterator { return s.Iter }
+
+// Generator is returned by calling a generator function. The body runs on
+// its own goroutine and pauses at each yield until the consumer asks for
+// the next value, so a generator can only be iterated once.
+type Generator struct {
+	Name string
+
+	run      func(g *Generator) Object
+	mu       sync.Mutex
+	started  bool
+	done     bool
+	stopping bool
+	steps    chan generatorStep
+	resume   chan bool
+}
+
+type generatorStep struct {
+	value Object
+	done  bool
+}
+
+// NewGenerator wraps run, which evaluates the generator body and returns
+// its final result once the body finishes.
+func NewGenerator(name string, run func(g *Generator) Object) *Generator {
+	return &Generator{Name: name, run: run, steps: make(chan generatorStep), resume: make(chan bool)}
+}
+
+func (g *Generator) Type() ObjectType  { return GENERATOR_OBJ }
+func (g *Generator) Inspect() string   { return fmt.Sprintf("generator(%s)", g.Name) }
+func (g *Generator) Iterate() Iterator { return g }
+
+// Next resumes the body until its next yield. An error raised by the body
+// is returned as the final value.
+func (g *Generator) Next() (Object, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.done {
+		return nil, false
+	}
+	if !g.started {
+		g.started = true
+		go func() {
+			g.steps <- generatorStep{value: g.run(g), done: true}
+		}()
+	} else {
+		g.resume <- true
+	}
+
+	step := <-g.steps
+	if step.done {
+		g.done = true
+		if step.value != nil && step.value.Type() == ERROR_OBJ {
+			return step.value, true
+		}
+		return nil, false
+	}
+	return step.value, true
+}
+
+// Yield is called from the body. It hands val to the consumer and reports
+// whether the body should keep running; false means the consumer stopped
+// early and the body should return.
+func (g *Generator) Yield(val Object) bool {
+	if g.stopping {
+		return false
+	}
+	g.steps <- generatorStep{value: val}
+	return <-g.resume
+}
+
+// Close stops a suspended body and waits for it to unwind.
+func (g *Generator) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.started && !g.done {
+		g.stopping = true
+		g.resume <- false
+		<-g.steps
+	}
+	g.done = true
+	return nil
+}
@@ -12,9 +12,13 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	LAMBDA
 	TERNARY
+	COALESCE
 	EQUALS      
 	LESSGREATER 
+	PIPE
+	RANGE
 	BITOR       
 	BITXOR      
 	BITAND      
@@ -35,6 +39,9 @@ var precedences = map[token.TokenType]int{
 	token.LTE:         LESSGREATER,
 	token.GT:          LESSGREATER,
 	token.GTE:         LESSGREATER,
+	token.DOTDOT:      RANGE,
+	token.PIPE:        PIPE,
+	token.ARROW:       LAMBDA,
 	token.BIT_OR:      BITOR,
 	token.BIT_XOR:     BITXOR,
 	token.BIT_AND:     BITAND,
@@ -49,6 +56,10 @@ var precedences = map[token.TokenType]int{
 	token.DOT:         INDEX,
 	token.LBRACKET:    INDEX,
 	token.QUESTION:    TERNARY,
+
+	token.NULLISH:           COALESCE,
+	token.OPTIONAL_DOT:      INDEX,
+	token.OPTIONAL_LBRACKET: INDEX,
 }
 
 type (
@@ -63,6 +74,11 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
+	loopDepth int
+	// yielded points at the Generator flag of the function being parsed,
+	// so a yield in its body marks it; nil outside of functions.
+	yielded *bool
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -78,6 +94,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE, p.parseInterpolatedString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -88,11 +105,17 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FOR, p.parseForExpression)
 	p.registerPrefix(token.FOREACH, p.parseForEachExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefix(token.TRY, p.parseTryCatchExpression)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.BIT_NOT, p.parsePrefixExpression)
+	p.registerPrefix(token.ASYNC, p.parseAsyncExpression)
+	p.registerPrefix(token.AWAIT, p.parseAwaitExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.SCHEDULE, p.parseIdentifier)
+	p.registerPrefix(token.SELECT, p.parseSelectExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -117,6 +140,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.LEFT_SHIFT, p.parseInfixExpression)
 	p.registerInfix(token.RIGHT_SHIFT, p.parseInfixExpression)
 	p.registerInfix(token.QUESTION, p.parseTernaryExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.DOTDOT, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.ARROW, p.parseArrowParameter)
+	p.registerInfix(token.OPTIONAL_DOT, p.parsePropertyAccessExpression)
+	p.registerInfix(token.OPTIONAL_LBRACKET, p.parseIndexExpression)
 
 	
 	p.nextToken()
@@ -153,7 +182,11 @@ func (p *Parser) Errors() []string {
 
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken.Pos, msg)
+}
+
+func (p *Parser) addError(pos token.Position, msg string) {
+	p.errors = append(p.errors, fmt.Sprintf("%s: %s", pos, msg))
 }
 
 
@@ -204,33 +237,54 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseImportStatement()
 	case token.SPAWN:
 		return p.parseSpawnStatement()
+	case token.SCHEDULE:
+		if !p.peekTokenIs(token.LPAREN) && !p.peekTokenIs(token.DOT) {
+			return p.parseScheduleStatement()
+		}
+		return p.parseExpressionOrAssignStatement()
+	case token.FUNCTION:
+		if p.peekTokenIs(token.IDENT) || p.peekTokenIs(token.ASTERISK) {
+			return p.parseFunctionStatement()
+		}
+		return p.parseExpressionOrAssignStatement()
+	case token.ASYNC:
+		return p.parseAsyncStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
 	case token.THROW:
 		return p.parseThrowStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
-		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
-			return p.parseAssignStatement()
-		}
-		return p.parseExpressionStatement()
+		return p.parseExpressionOrAssignStatement()
 	}
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		stmt.Pattern = p.parsePattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
+	if stmt.Name != nil {
+		nameFunctionLiteral(stmt.Value, stmt.Name.Value)
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -239,16 +293,59 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
-func (p *Parser) parseAssignStatement() *ast.AssignStatement {
-	stmt := &ast.AssignStatement{Token: p.curToken}
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+var assignOperators = map[token.TokenType]bool{
+	token.ASSIGN:          true,
+	token.PLUS_ASSIGN:     true,
+	token.MINUS_ASSIGN:    true,
+	token.ASTERISK_ASSIGN: true,
+	token.SLASH_ASSIGN:    true,
+}
 
-	if !p.expectPeek(token.ASSIGN) {
+func (p *Parser) parseExpressionOrAssignStatement() ast.Statement {
+	tok := p.curToken
+	exp := p.parseExpression(LOWEST)
+
+	if !assignOperators[p.peekToken.Type] {
+		stmt := &ast.ExpressionStatement{Token: tok, Expression: exp}
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+		return stmt
+	}
+
+	return p.parseAssignStatement(tok, exp)
+}
+
+func (p *Parser) parseAssignStatement(tok token.Token, target ast.Expression) *ast.AssignStatement {
+	stmt := &ast.AssignStatement{Token: tok, Target: target}
+
+	switch target := target.(type) {
+	case *ast.Identifier:
+	case *ast.IndexExpression:
+		if target.Optional {
+			p.addError(tok.Pos, fmt.Sprintf("cannot assign to optional chain %s", target))
+			return nil
+		}
+	case *ast.PropertyAccessExpression:
+		if target.Optional {
+			p.addError(tok.Pos, fmt.Sprintf("cannot assign to optional chain %s", target))
+			return nil
+		}
+	case nil:
+		return nil
+	default:
+		p.addError(tok.Pos, fmt.Sprintf("cannot assign to %s", target))
 		return nil
 	}
 
+	p.nextToken()
+	stmt.Operator = p.curToken.Literal
+
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
+	if ident, ok := target.(*ast.Identifier); ok {
+		nameFunctionLiteral(stmt.Value, ident.Value)
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -270,15 +367,21 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{Token: p.curToken}
-	stmt.Expression = p.parseExpression(LOWEST)
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	if p.loopDepth == 0 {
+		p.addError(tok.Pos, fmt.Sprintf("%s outside of loop", tok.Literal))
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
-	return stmt
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -304,7 +407,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken.Pos, msg)
 }
 
 
@@ -318,7 +421,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos, msg)
 		return nil
 	}
 
@@ -332,7 +435,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos, msg)
 		return nil
 	}
 
@@ -344,6 +447,71 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	tok := p.curToken
+	raw := tok.Literal
+	expression := &ast.InterpolatedString{Token: tok}
+
+	addText := func(text string) {
+		if text != "" {
+			textTok := token.Token{Type: token.TEMPLATE, Literal: text, Pos: tok.Pos}
+			expression.Parts = append(expression.Parts, &ast.StringLiteral{Token: textTok, Value: lexer.Unescape(text)})
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' {
+			i++
+			continue
+		}
+		if raw[i] != '$' || i+1 == len(raw) || raw[i+1] != '{' {
+			continue
+		}
+
+		addText(raw[start:i])
+		pos := stringOffset(tok.Pos, raw[:i+2])
+		end := lexer.InterpolationEnd(raw, i+2)
+		if end == len(raw) {
+			p.addError(pos, "unterminated string interpolation")
+			return nil
+		}
+
+		sub := New(lexer.NewAt(raw[i+2:end], pos))
+		if sub.curTokenIs(token.EOF) {
+			p.addError(pos, "empty string interpolation")
+			return nil
+		}
+		part := sub.parseExpression(LOWEST)
+		if !sub.peekTokenIs(token.EOF) {
+			sub.addError(sub.peekToken.Pos, fmt.Sprintf("unexpected %s in string interpolation", sub.peekToken.Literal))
+		}
+		p.errors = append(p.errors, sub.errors...)
+		expression.Parts = append(expression.Parts, part)
+
+		i = end
+		start = end + 1
+	}
+	addText(raw[start:])
+
+	return expression
+}
+
+// stringOffset returns the position reached after text inside a string
+// literal that opens at pos.
+func stringOffset(pos token.Position, text string) token.Position {
+	pos.Column++
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -361,10 +529,37 @@ func (p *Parser) parseBoolean() ast.Expression {
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	destructured := (p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE)) && p.arrowAhead()
+	if p.peekTokenIs(token.RPAREN) || p.peekTokenIs(token.ELLIPSIS) || destructured {
+		params := p.parseFunctionParameters()
+		if params == nil || !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		return p.parseArrowFunction(params)
+	}
+
 	p.nextToken()
 
 	exp := p.parseExpression(LOWEST)
 
+	// (a, b) => ... is the only place a comma or default can follow.
+	if ident, ok := exp.(*ast.Identifier); ok && (p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.ASSIGN)) {
+		params := []*ast.Parameter{p.parseParameterDefault(&ast.Parameter{Token: ident.Token, Name: ident})}
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			params = append(params, p.parseParameter())
+		}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+		p.checkRestParameters(params)
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		return p.parseArrowFunction(params)
+	}
+
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
@@ -372,6 +567,83 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	return exp
 }
 
+// arrowAhead reports whether the parenthesis at the current token closes
+// right before a =>, so ([a, b]) => ... can be told apart from a grouped
+// array literal. It scans a copy of the lexer and leaves the parser as is.
+func (p *Parser) arrowAhead() bool {
+	l := *p.l
+	tok := p.peekToken
+	for depth := 1; tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.OPTIONAL_LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		}
+		if depth == 0 {
+			return l.NextToken().Type == token.ARROW
+		}
+	}
+	return false
+}
+
+// parseArrowParameter handles the single-parameter form x => ...; the
+// parenthesised forms are handled by parseGroupedExpression.
+func (p *Parser) parseArrowParameter(left ast.Expression) ast.Expression {
+	ident, ok := left.(*ast.Identifier)
+	if !ok {
+		// left may be nil or half-built after its own parse error, so it
+		// is not printed.
+		p.addError(p.curToken.Pos, "arrow function parameter must be a name or a parenthesised list")
+		return nil
+	}
+	return p.parseArrowFunction([]*ast.Parameter{{Token: ident.Token, Name: ident}})
+}
+
+// parseArrowFunction parses the body after =>. A block is used as is and
+// any other expression becomes the returned value.
+func (p *Parser) parseArrowFunction(params []*ast.Parameter) ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken, Arrow: true, Parameters: params}
+
+	loopDepth, yielded := p.loopDepth, p.yielded
+	p.loopDepth, p.yielded = 0, &lit.Generator
+	defer func() { p.loopDepth, p.yielded = loopDepth, yielded }()
+
+	p.nextToken()
+	if p.curTokenIs(token.LBRACE) {
+		lit.Body = p.parseBlockStatement()
+		return lit
+	}
+
+	tok := p.curToken
+	exp := p.parseExpression(LOWEST)
+	if exp == nil {
+		return nil
+	}
+	ret := token.Token{Type: token.RETURN, Literal: "return", Pos: tok.Pos}
+	lit.Body = &ast.BlockStatement{Token: tok, Statements: []ast.Statement{
+		&ast.ReturnStatement{Token: ret, ReturnValue: exp},
+	}}
+	return lit
+}
+
+// parsePipeExpression rewrites x |> f(a) into f(x, a) and x |> f into f(x).
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	p.nextToken()
+	right := p.parseExpression(PIPE)
+	if right == nil {
+		return nil
+	}
+
+	if call, ok := right.(*ast.CallExpression); ok {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		return call
+	}
+	return &ast.CallExpression{Token: tok, Function: right, Arguments: []ast.Expression{left}}
+}
+
 
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
@@ -406,10 +678,12 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		if p.peekTokenIs(token.IF) {
 			p.nextToken()
 			
+			ifToken := p.curToken
 			nestedIf := p.parseIfExpression()
 			expression.Alternative = &ast.BlockStatement{
+				Token: ifToken,
 				Statements: []ast.Statement{
-					&ast.ExpressionStatement{Expression: nestedIf},
+					&ast.ExpressionStatement{Token: ifToken, Expression: nestedIf},
 				},
 			}
 		} else if p.expectPeek(token.LBRACE) {
@@ -438,9 +712,139 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 }
 
 
+func (p *Parser) parseSelectExpression() ast.Expression {
+	expression := &ast.SelectExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) {
+		switch {
+		case p.curTokenIs(token.EOF):
+			p.addError(p.curToken.Pos, "unterminated select")
+			return nil
+		case p.curTokenIs(token.SEMICOLON):
+		case p.curTokenIs(token.ELSE):
+			if !p.expectPeek(token.LBRACE) {
+				return nil
+			}
+			expression.Default = p.parseBlockStatement()
+		case p.curTokenIs(token.IDENT) && p.peekTokenIs(token.IN):
+			c := &ast.SelectCase{Var: p.curToken.Literal}
+			p.nextToken()
+			p.nextToken()
+			c.Channel = p.parseExpression(LOWEST)
+			if !p.expectPeek(token.LBRACE) {
+				return nil
+			}
+			c.Body = p.parseBlockStatement()
+			expression.Cases = append(expression.Cases, c)
+		case p.curTokenIs(token.IDENT) && p.curToken.Literal == "after":
+			p.nextToken()
+			expression.Timeout = p.parseExpression(LOWEST)
+			if !p.expectPeek(token.LBRACE) {
+				return nil
+			}
+			expression.TimeoutBody = p.parseBlockStatement()
+		default:
+			p.addError(p.curToken.Pos, fmt.Sprintf("expected `name in channel`, `after` or `else` in select, got %s", p.curToken.Type))
+			return nil
+		}
+		p.nextToken()
+	}
+
+	return expression
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.addError(p.curToken.Pos, "unterminated match")
+			return nil
+		}
+
+		arm := &ast.MatchArm{Pattern: p.parseMatchPattern()}
+		if arm.Pattern == nil {
+			return nil
+		}
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.parseExpression(LAMBDA)
+		}
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		p.nextToken()
+
+		if p.curTokenIs(token.LBRACE) {
+			arm.Body = p.parseBlockStatement()
+		} else {
+			tok := p.curToken
+			exp := p.parseExpression(LOWEST)
+			arm.Body = &ast.BlockStatement{Token: tok, Statements: []ast.Statement{
+				&ast.ExpressionStatement{Token: tok, Expression: exp},
+			}}
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		p.nextToken()
+		for p.curTokenIs(token.COMMA) || p.curTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+	}
+
+	return expression
+}
+
+// parseMatchPattern parses a match arm pattern: a literal, an inclusive
+// numeric range (400..499), `_`, a binding identifier, or an array or hash
+// pattern whose elements are match patterns themselves.
+func (p *Parser) parseMatchPattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET, token.LBRACE:
+		return p.parseStructuredPattern(p.parseMatchPattern)
+	case token.INT, token.FLOAT, token.STRING, token.TEMPLATE, token.TRUE, token.FALSE, token.MINUS:
+		tok := p.curToken
+		literal := p.parseExpression(PREFIX)
+		if !p.peekTokenIs(token.DOTDOT) {
+			return literal
+		}
+		p.nextToken()
+		p.nextToken()
+		return &ast.RangePattern{Token: tok, Low: literal, High: p.parseExpression(PREFIX)}
+	}
+
+	p.addError(p.curToken.Pos, fmt.Sprintf("expected pattern in match arm, got %s", p.curToken.Type))
+	return nil
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
 
+	if p.peekTokenIs(token.ASTERISK) {
+		p.nextToken()
+		lit.Generator = true
+	}
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		lit.Name = p.curToken.Literal
+	}
+
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
@@ -451,36 +855,248 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	loopDepth, yielded := p.loopDepth, p.yielded
+	p.loopDepth, p.yielded = 0, &lit.Generator
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth, p.yielded = loopDepth, yielded
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parseFunctionStatement parses `function name(...) {...}` (or function*)
+// into a let binding of the function literal.
+func (p *Parser) parseFunctionStatement() ast.Statement {
+	tok := p.curToken
+	lit, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	if lit.Name == "" {
+		return &ast.ExpressionStatement{Token: tok, Expression: lit}
+	}
+	return bindFunction(tok, lit)
+}
+
+// parseAsyncStatement binds `async function name(...) {...}` the same way
+// as a function statement; any other async expression is left as is.
+func (p *Parser) parseAsyncStatement() ast.Statement {
+	stmt := p.parseExpressionOrAssignStatement()
+	if exp, ok := stmt.(*ast.ExpressionStatement); ok {
+		if lit, ok := exp.Expression.(*ast.FunctionLiteral); ok && lit.Name != "" {
+			return bindFunction(exp.Token, lit)
+		}
+	}
+	return stmt
+}
+
+func bindFunction(tok token.Token, lit *ast.FunctionLiteral) *ast.LetStatement {
+	name := &ast.Identifier{Token: tok, Value: lit.Name}
+	name.Token.Literal = lit.Name
+	return &ast.LetStatement{Token: tok, Name: name, Value: lit}
+}
+
+func (p *Parser) parseYieldExpression() ast.Expression {
+	exp := &ast.YieldExpression{Token: p.curToken}
+
+	if p.yielded == nil {
+		p.addError(p.curToken.Pos, "yield outside of function")
+		return nil
+	}
+	*p.yielded = true
+
+	if !p.peekTokenIs(token.SEMICOLON) && !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		exp.Value = p.parseExpression(LOWEST)
+	}
+
+	return exp
+}
+
+func nameFunctionLiteral(exp ast.Expression, name string) {
+	if fn, ok := exp.(*ast.FunctionLiteral); ok && fn.Name == "" {
+		fn.Name = name
+	}
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	params := []*ast.Parameter{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return params
 	}
 
 	p.nextToken()
-
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+	params = append(params, p.parseParameter())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
+		params = append(params, p.parseParameter())
 	}
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
+	p.checkRestParameters(params)
+
+	return params
+}
+
+func (p *Parser) checkRestParameters(params []*ast.Parameter) {
+	for i, param := range params {
+		if param != nil && param.Rest && i != len(params)-1 {
+			p.addError(param.Token.Pos, "rest parameter must be last")
+		}
+	}
+}
+
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{Token: p.curToken}
+
+	if p.curTokenIs(token.ELLIPSIS) {
+		param.Rest = true
+		p.nextToken()
+	}
+
+	switch {
+	case p.curTokenIs(token.IDENT):
+		param.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case !param.Rest && (p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE)):
+		param.Pattern = p.parsePattern()
+		if param.Pattern == nil {
+			return nil
+		}
+	default:
+		p.addError(p.curToken.Pos, fmt.Sprintf("expected parameter name, got %s", p.curToken.Type))
+		return nil
+	}
+
+	return p.parseParameterDefault(param)
+}
+
+// parseParameterDefault parses an optional `= default` after a parameter.
+func (p *Parser) parseParameterDefault(param *ast.Parameter) *ast.Parameter {
+	if p.peekTokenIs(token.ASSIGN) {
+		if param.Rest {
+			p.addError(p.peekToken.Pos, "rest parameter cannot have a default value")
+			return nil
+		}
+		p.nextToken()
+		p.nextToken()
+		param.Default = p.parseExpression(LOWEST)
+	}
 
-	return identifiers
+	return param
+}
+
+// parsePattern parses a destructuring target starting at the current
+// token: an identifier, [a, b, ...rest] or {key, key: target, ...rest}.
+// Every element may carry a default value.
+func (p *Parser) parsePattern() ast.Expression {
+	if p.curTokenIs(token.IDENT) {
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	if pattern := p.parseStructuredPattern(p.parsePattern); pattern != nil {
+		return pattern
+	}
+	return nil
+}
+
+// parseStructuredPattern parses an array or hash pattern whose elements
+// are parsed by target, so match arms can reuse it with literal patterns.
+func (p *Parser) parseStructuredPattern(target func() ast.Expression) ast.Expression {
+	switch p.curToken.Type {
+	case token.LBRACKET:
+		pattern := &ast.ArrayPattern{Token: p.curToken}
+		rest, ok := p.parsePatternElements(token.RBRACKET, func() *ast.PatternElement {
+			el := target()
+			if el == nil {
+				return nil
+			}
+			return &ast.PatternElement{Target: el}
+		}, &pattern.Elements)
+		if !ok {
+			return nil
+		}
+		pattern.Rest = rest
+		return pattern
+	case token.LBRACE:
+		pattern := &ast.HashPattern{Token: p.curToken}
+		rest, ok := p.parsePatternElements(token.RBRACE, func() *ast.PatternElement {
+			if !p.curTokenIs(token.IDENT) && !p.curTokenIs(token.STRING) {
+				p.addError(p.curToken.Pos, fmt.Sprintf("expected key in hash pattern, got %s", p.curToken.Type))
+				return nil
+			}
+			entry := &ast.PatternElement{Key: p.curToken.Literal}
+			if !p.peekTokenIs(token.COLON) {
+				if !p.curTokenIs(token.IDENT) {
+					p.addError(p.curToken.Pos, "string key in hash pattern needs a target")
+					return nil
+				}
+				entry.Target = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+				return entry
+			}
+			p.nextToken()
+			p.nextToken()
+			entry.Target = target()
+			if entry.Target == nil {
+				return nil
+			}
+			return entry
+		}, &pattern.Entries)
+		if !ok {
+			return nil
+		}
+		pattern.Rest = rest
+		return pattern
+	}
+
+	p.addError(p.curToken.Pos, fmt.Sprintf("expected identifier or pattern, got %s", p.curToken.Type))
+	return nil
+}
+
+func (p *Parser) parsePatternElements(end token.TokenType, parseElement func() *ast.PatternElement, elements *[]*ast.PatternElement) (*ast.Identifier, bool) {
+	var rest *ast.Identifier
+
+	for !p.peekTokenIs(end) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil, false
+			}
+			rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.peekTokenIs(end) {
+				p.addError(p.peekToken.Pos, "rest element must be last")
+				return nil, false
+			}
+			break
+		}
+
+		el := parseElement()
+		if el == nil {
+			return nil, false
+		}
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			el.Default = p.parseExpression(LOWEST)
+		}
+		*elements = append(*elements, el)
+
+		if !p.peekTokenIs(end) && !p.expectPeek(token.COMMA) {
+			return nil, false
+		}
+	}
+
+	if !p.expectPeek(end) {
+		return nil, false
+	}
+	return rest, true
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -498,12 +1114,12 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	args = append(args, p.parseCallArgument())
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		args = append(args, p.parseCallArgument())
 	}
 
 	if !p.expectPeek(token.RPAREN) {
@@ -513,10 +1129,23 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 	return args
 }
 
+func (p *Parser) parseCallArgument() ast.Expression {
+	if !p.curTokenIs(token.IDENT) || !p.peekTokenIs(token.COLON) {
+		return p.parseExpression(LOWEST)
+	}
+
+	arg := &ast.NamedArgument{Token: p.curToken, Name: p.curToken.Literal}
+	p.nextToken()
+	p.nextToken()
+	arg.Value = p.parseExpression(LOWEST)
+	return arg
+}
+
 func (p *Parser) parsePropertyAccessExpression(left ast.Expression) ast.Expression {
 	exp := &ast.PropertyAccessExpression{
-		Token: p.curToken,
-		Left:  left,
+		Token:    p.curToken,
+		Left:     left,
+		Optional: p.curTokenIs(token.OPTIONAL_DOT),
 	}
 
 	if !p.expectPeek(token.IDENT) {
@@ -529,16 +1158,40 @@ func (p *Parser) parsePropertyAccessExpression(left ast.Expression) ast.Expressi
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
+	optional := p.curTokenIs(token.OPTIONAL_LBRACKET)
 
-	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	var index ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		index = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		slice := &ast.SliceExpression{Token: tok, Left: left, Low: index, Optional: optional}
+		if !p.peekTokenIs(token.RBRACKET) {
+			p.nextToken()
+			slice.High = p.parseExpression(LOWEST)
+		}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return slice
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
-	return exp
+	return &ast.IndexExpression{Token: tok, Left: left, Index: index, Optional: optional}
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+	return body
 }
 
 func (p *Parser) parseWhileExpression() ast.Expression {
@@ -564,7 +1217,7 @@ func (p *Parser) parseWhileExpression() ast.Expression {
 		return nil
 	}
 
-	expression.Body = p.parseBlockStatement()
+	expression.Body = p.parseLoopBody()
 
 	return expression
 }
@@ -626,7 +1279,7 @@ func (p *Parser) parseForExpression() ast.Expression {
 		return nil
 	}
 
-	expression.Body = p.parseBlockStatement()
+	expression.Body = p.parseLoopBody()
 
 	return expression
 }
@@ -643,25 +1296,33 @@ func (p *Parser) parseForEachExpression() ast.Expression {
 	}
 
 	
-	if !p.curTokenIs(token.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("expected identifier, got %s", p.curToken.Type))
+	if p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE) {
+		expression.ValuePattern = p.parsePattern()
+		if expression.ValuePattern == nil {
+			return nil
+		}
+	} else if !p.curTokenIs(token.IDENT) {
+		p.addError(p.curToken.Pos, fmt.Sprintf("expected identifier, got %s", p.curToken.Type))
 		return nil
-	}
-	firstId := p.curToken.Literal
-
-	
-	if p.peekTokenIs(token.COMMA) {
-		expression.KeyVar = firstId
+	} else if p.peekTokenIs(token.COMMA) {
+		expression.KeyVar = p.curToken.Literal
 		p.nextToken() 
 		p.nextToken() 
 
-		if !p.curTokenIs(token.IDENT) {
-			p.errors = append(p.errors, fmt.Sprintf("expected identifier after comma, got %s", p.curToken.Type))
+		switch {
+		case p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE):
+			expression.ValuePattern = p.parsePattern()
+			if expression.ValuePattern == nil {
+				return nil
+			}
+		case p.curTokenIs(token.IDENT):
+			expression.ValueVar = p.curToken.Literal
+		default:
+			p.addError(p.curToken.Pos, fmt.Sprintf("expected identifier after comma, got %s", p.curToken.Type))
 			return nil
 		}
-		expression.ValueVar = p.curToken.Literal
 	} else {
-		expression.ValueVar = firstId
+		expression.ValueVar = p.curToken.Literal
 	}
 
 	
@@ -682,7 +1343,7 @@ func (p *Parser) parseForEachExpression() ast.Expression {
 		return nil
 	}
 
-	expression.Body = p.parseBlockStatement()
+	expression.Body = p.parseLoopBody()
 
 	return expression
 }
@@ -733,6 +1394,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		value := p.parseExpression(LOWEST)
 
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -817,6 +1479,7 @@ func (p *Parser) parseGlobalStatement() *ast.GlobalStatement {
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	nameFunctionLiteral(stmt.Value, stmt.Name.Value)
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -858,7 +1521,7 @@ func (p *Parser) parseSpawnStatement() *ast.SpawnStatement {
 	expression := p.parseExpression(LOWEST)
 	call, ok := expression.(*ast.CallExpression)
 	if !ok {
-		p.errors = append(p.errors, "spawn must be followed by a function call")
+		p.addError(p.curToken.Pos, "spawn must be followed by a function call")
 		return nil
 	}
 
@@ -870,6 +1533,70 @@ func (p *Parser) parseSpawnStatement() *ast.SpawnStatement {
 
 	return stmt
 }
+
+func (p *Parser) parseScheduleStatement() *ast.ScheduleStatement {
+	stmt := &ast.ScheduleStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Spec = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.AS) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	loopDepth, yielded := p.loopDepth, p.yielded
+	p.loopDepth, p.yielded = 0, nil
+	stmt.Body = p.parseBlockStatement()
+	p.loopDepth, p.yielded = loopDepth, yielded
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseAsyncExpression() ast.Expression {
+	tok := p.curToken
+
+	if p.peekTokenIs(token.FUNCTION) {
+		p.nextToken()
+		lit, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+		if !ok {
+			return nil
+		}
+		lit.Async = true
+		return lit
+	}
+
+	p.nextToken()
+
+	call, ok := p.parseExpression(PREFIX).(*ast.CallExpression)
+	if !ok {
+		p.addError(tok.Pos, "async must be followed by a function literal or a function call")
+		return nil
+	}
+
+	return &ast.AsyncExpression{Token: tok, Call: call}
+}
+
+func (p *Parser) parseAwaitExpression() ast.Expression {
+	expression := &ast.AwaitExpression{Token: p.curToken}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(PREFIX)
+
+	return expression
+}
+
 func (p *Parser) parseTernaryExpression(condition ast.Expression) ast.Expression {
 	expression := &ast.TernaryExpression{
 		Token:     p.curToken,
@@ -878,7 +1605,7 @@ func (p *Parser) parseTernaryExpression(condition ast.Expression) ast.Expression
 
 	p.nextToken() 
 
-	expression.Consequence = p.parseExpression(TERNARY)
+	expression.Consequence = p.parseTernaryBranch()
 
 	if !p.expectPeek(token.COLON) {
 		return nil
@@ -886,7 +1613,18 @@ func (p *Parser) parseTernaryExpression(condition ast.Expression) ast.Expression
 
 	p.nextToken() 
 
-	expression.Alternative = p.parseExpression(TERNARY)
+	expression.Alternative = p.parseTernaryBranch()
 
 	return expression
 }
+
+// parseTernaryBranch parses one side of a ternary. Branches bind tighter
+// than =>, so a bare x => ... arrow has to be picked up here.
+func (p *Parser) parseTernaryBranch() ast.Expression {
+	branch := p.parseExpression(TERNARY)
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		return p.parseArrowParameter(branch)
+	}
+	return branch
+}
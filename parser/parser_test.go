@@ -3,6 +3,7 @@ package parser
 import (
 	"base/ast"
 	"base/lexer"
+	"strings"
 	"testing"
 )
 
@@ -67,6 +68,414 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	return true
 }
 
+func TestParseErrorPositions(t *testing.T) {
+	input := `let x = 5;
+let = 10;`
+
+	l := lexer.NewWithFile(input, "main.base")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parse errors, got none")
+	}
+	if !strings.HasPrefix(errors[0], "main.base:2:5: ") {
+		t.Errorf("error does not start with position. got=%q", errors[0])
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of loop"},
+		{"while (true) { let f = function() { continue; }; }", "1:37: continue outside of loop"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q, want=%q", tt.input, errors, tt.expected)
+		}
+	}
+}
+
+func TestScheduleStatement(t *testing.T) {
+	input := `schedule "*/5 * * * *" as cleanup { print("tick"); }`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has wrong number of statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ScheduleStatement)
+	if !ok {
+		t.Fatalf("statement is not *ast.ScheduleStatement. got=%T", program.Statements[0])
+	}
+	if stmt.Spec.String() != "*/5 * * * *" {
+		t.Errorf("wrong spec. got=%q", stmt.Spec.String())
+	}
+	if stmt.Name == nil || stmt.Name.Value != "cleanup" {
+		t.Errorf("wrong job name. got=%v", stmt.Name)
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body has wrong number of statements. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestSelectExpression(t *testing.T) {
+	input := `select { msg in jobs { print(msg); } after 2 { print("idle"); } else { print("busy"); } }`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	sel, ok := stmt.Expression.(*ast.SelectExpression)
+	if !ok {
+		t.Fatalf("expression is not *ast.SelectExpression. got=%T", stmt.Expression)
+	}
+	if len(sel.Cases) != 1 || sel.Cases[0].Var != "msg" || sel.Cases[0].Channel.String() != "jobs" {
+		t.Errorf("wrong select cases. got=%s", sel.String())
+	}
+	if sel.Timeout == nil || sel.Timeout.String() != "2" {
+		t.Errorf("wrong select timeout. got=%v", sel.Timeout)
+	}
+	if sel.Default == nil {
+		t.Errorf("select default branch missing")
+	}
+}
+
+func TestAssignStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5;", "x = 5;"},
+		{"xs[0] += 1;", "(xs[0]) += 1;"},
+		{"cfg.db.port = 5433;", "cfg.db.port = 5433;"},
+		{"total /= 2", "total /= 2;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.AssignStatement)
+		if !ok {
+			t.Fatalf("statement is not *ast.AssignStatement. got=%T", program.Statements[0])
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("wrong assignment. got=%q, want=%q", stmt.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New("1 = 2;"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "1:1: cannot assign to 1" {
+		t.Errorf("wrong errors for invalid target. got=%q", p.Errors())
+	}
+}
+
+func TestInterpolatedString(t *testing.T) {
+	p := New(lexer.New(`"Hello ${user.name}, you have ${count + 1} new"`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("expression is not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+	if len(str.Parts) != 5 {
+		t.Fatalf("wrong number of parts. got=%d", len(str.Parts))
+	}
+	if str.String() != `"Hello ${user.name}, you have ${(count + 1)} new"` {
+		t.Errorf("wrong string. got=%s", str.String())
+	}
+
+	p = New(lexer.New("let x = 1;\nprint(\"total: ${x +}\")"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "2:20: no prefix parse function for EOF found" {
+		t.Errorf("wrong errors for bad interpolation. got=%q", p.Errors())
+	}
+
+	p = New(lexer.New(`let s = "a ${b`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "1:14: unterminated string interpolation" {
+		t.Errorf("wrong errors for unterminated interpolation. got=%q", p.Errors())
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"function(a, b = 10) { a; }", "function(a, b = 10) a"},
+		{"function(first, ...rest) { rest; }", "function(first, ...rest) rest"},
+		{"connect(host: \"db\", port: 5432)", "connect(host: db, port: 5432)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New("function(...rest, last) { last; }"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "1:10: rest parameter must be last" {
+		t.Errorf("wrong errors for misplaced rest parameter. got=%q", p.Errors())
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let {status, body: text} = r;", "let {status, body: text} = r;"},
+		{"let [first, second = 2, ...rest] = xs;", "let [first, second = 2, ...rest] = xs;"},
+		{"let {db: {host, port = 5432}, ...others} = cfg;", "let {db: {host, port = 5432}, ...others} = cfg;"},
+		{"foreach {name, email} in users { name }", "foreach ({name, email} in users) name"},
+		{"function([a, b], {c}) { a }", "function([a, b], {c}) a"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New("let [...rest, last] = xs;"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "1:13: rest element must be last" {
+		t.Errorf("wrong errors for misplaced rest element. got=%q", p.Errors())
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	input := `match code {
+	200 => "ok",
+	-1 => "none"
+	400..499 => { "client" }
+	{"type": "push", ref} if ref != "" => ref,
+	[x, ...rest] => x,
+	_ => "other"
+}`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	match, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("expression is not *ast.MatchExpression. got=%T", stmt.Expression)
+	}
+	if len(match.Arms) != 6 {
+		t.Fatalf("wrong number of arms. got=%d", len(match.Arms))
+	}
+	if _, ok := match.Arms[2].Pattern.(*ast.RangePattern); !ok {
+		t.Errorf("arm 2 is not a range pattern. got=%T", match.Arms[2].Pattern)
+	}
+	if match.Arms[3].Guard == nil || match.Arms[3].Guard.String() != `(ref != )` {
+		t.Errorf("wrong guard on arm 3. got=%v", match.Arms[3].Guard)
+	}
+	if match.Arms[4].Pattern.String() != "[x, ...rest]" {
+		t.Errorf("wrong pattern on arm 4. got=%s", match.Arms[4].Pattern.String())
+	}
+}
+
+func TestNullSafetyOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a?.b?.c", "a?.b?.c"},
+		{"xs?[0]", "(xs?[0])"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a?.b ?? x == y", "(a?.b ?? (x == y))"},
+		{"ok ? a : b", "(ok ? a : b)"},
+		{"ok ?[1] : [2]", "(ok ? [1] : [2])"},
+		{"ok ? xs?[0] : [2]", "(ok ? (xs?[0]) : [2])"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs[1:3]", "(xs[1:3])"},
+		{"xs[:n]", "(xs[:n])"},
+		{"s[2:]", "(s[2:])"},
+		{"xs[:]", "(xs[:])"},
+		{"xs[-1]", "(xs[(-1)])"},
+		{"xs?[1:-1]", "(xs?[1:(-1)])"},
+		{"xs[ok ? 1 : 2]", "(xs[(ok ? 1 : 2)])"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New("xs[1:2] = [0]"))
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) == 0 || errors[0] != "1:1: cannot assign to (xs[1:2])" {
+		t.Errorf("wrong errors for slice assignment. got=%q", errors)
+	}
+}
+
+func TestGeneratorFunctions(t *testing.T) {
+	tests := []struct {
+		input     string
+		name      string
+		generator bool
+	}{
+		{"function* pages() { return 1 }", "pages", true},
+		{"let count = function(n) { yield n }", "count", true},
+		{"function outer() { let inner = function() { yield 1 } }", "outer", false},
+		{"function plain(a, b) { return a + b }", "plain", false},
+		{"async function fetchit(x) { return x }", "fetchit", false},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("statement is not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+		fn, ok := stmt.Value.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("value is not *ast.FunctionLiteral. got=%T", stmt.Value)
+		}
+		if stmt.Name.Value != tt.name || fn.Name != tt.name {
+			t.Errorf("wrong name for %q. got=%s/%s", tt.input, stmt.Name.Value, fn.Name)
+		}
+		if fn.Generator != tt.generator {
+			t.Errorf("wrong generator flag for %q. got=%t", tt.input, fn.Generator)
+		}
+	}
+
+	p := New(lexer.New("async function fetchit(x) { return x }"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if stmt, ok := program.Statements[0].(*ast.LetStatement); !ok || !stmt.Value.(*ast.FunctionLiteral).Async {
+		t.Errorf("named async function is not an async let binding. got=%s", program.String())
+	}
+
+	p = New(lexer.New("yield 1"))
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) == 0 || errors[0] != "1:1: yield outside of function" {
+		t.Errorf("wrong errors for top-level yield. got=%q", errors)
+	}
+}
+
+func TestArrowFunctionsAndPipes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x => x * 2", "(x) => return (x * 2);"},
+		{"(a, b = 1) => a + b", "(a, b = 1) => return (a + b);"},
+		{"() => 42", "() => return 42;"},
+		{"(...xs) => xs", "(...xs) => return xs;"},
+		{"a => b => a + b", "(a) => return (b) => return (a + b);;"},
+		{"xs |> f", "f(xs)"},
+		{"xs |> list.map(x => x * 2, 1)", "list.map(xs, (x) => return (x * 2);, 1)"},
+		{"xs |> f |> g(1)", "g(f(xs), 1)"},
+		{"a |> f == b", "(f(a) == b)"},
+		{"x + 1 |> f", "f((x + 1))"},
+		{"ok ? x => x + 1 : x => x - 1", "(ok ? (x) => return (x + 1); : (x) => return (x - 1);)"},
+		{"([a, b]) => a", "([a, b]) => return a;"},
+		{"({name}, n = 1) => name", "({name}, n = 1) => return name;"},
+		{"([1, 2])", "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"a + b => 1", "1:7: arrow function parameter must be a name or a parenthesised list"},
+		{"(1 +) => 2", "1:7: arrow function parameter must be a name or a parenthesised list"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if errors := strings.Join(p.Errors(), "\n"); !strings.Contains(errors, tt.expected) {
+			t.Errorf("wrong errors for %q. got=%q, want=%q", tt.input, errors, tt.expected)
+		}
+	}
+}
+
+func TestMalformedNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.2.3", `1:1: could not parse "1.2.3" as float`},
+		{"let x = 0xFG", `1:9: could not parse "0xFG" as integer`},
+		{"1__000", `1:1: could not parse "1__000" as integer`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q, want=%q", tt.input, errors, tt.expected)
+		}
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -1,10 +1,28 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
 }
 
 const (
@@ -12,10 +30,11 @@ const (
 	EOF     = "EOF"
 
 	
-	IDENT  = "IDENT"  
-	INT    = "INT"    
-	FLOAT  = "FLOAT"  
-	STRING = "STRING" 
+	IDENT    = "IDENT"
+	INT      = "INT"
+	FLOAT    = "FLOAT"
+	STRING   = "STRING"
+	TEMPLATE = "TEMPLATE"
 
 	
 	ASSIGN   = "="
@@ -34,6 +53,11 @@ const (
 	LTE    = "<="
 	GTE    = ">="
 
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+
 	
 	AND         = "and"
 	OR          = "or"
@@ -44,15 +68,23 @@ const (
 	BIT_NOT     = "~"
 	LEFT_SHIFT  = "<<"
 	RIGHT_SHIFT = ">>"
+	PIPE        = "|>"
 
 	
 	QUESTION = "?"
 	COLON    = ":"
 
+	NULLISH           = "??"
+	OPTIONAL_DOT      = "?."
+	OPTIONAL_LBRACKET = "?["
+
 	
 	COMMA     = ","
 	SEMICOLON = ";"
 	DOT       = "."
+	DOTDOT    = ".."
+	ELLIPSIS  = "..."
+	ARROW     = "=>"
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -80,8 +112,14 @@ const (
 	CATCH    = "CATCH"
 	THROW    = "THROW"
 	ASYNC    = "ASYNC"
+	AWAIT    = "AWAIT"
 	SPAWN    = "SPAWN"
 	SCHEDULE = "SCHEDULE"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	SELECT   = "SELECT"
+	MATCH    = "MATCH"
+	YIELD    = "YIELD"
 )
 
 var keywords = map[string]TokenType{
@@ -102,9 +140,15 @@ var keywords = map[string]TokenType{
 	"try":      TRY,
 	"catch":    CATCH,
 	"throw":    THROW,
+	"yield":    YIELD,
 	"async":    ASYNC,
+	"await":    AWAIT,
 	"spawn":    SPAWN,
 	"schedule": SCHEDULE,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"select":   SELECT,
+	"match":    MATCH,
 	"and":      AND,
 	"or":       OR,
 	"not":      NOT,